@@ -0,0 +1,24 @@
+// Package cachetest backs the cache package with an in-memory Redis for tests
+package cachetest
+
+import (
+	"go-server/cache"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+// Start points the cache package at a fresh in-memory Redis for the length
+// of the test
+func Start(t testing.TB) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	cache.Use(client)
+	t.Cleanup(func() {
+		cache.Use(nil)
+		client.Close()
+	})
+	return mr
+}
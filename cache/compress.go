@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log"
+	"os"
+	"strconv"
+)
+
+const defaultCompressMinBytes = 1024
+
+// gzipMagic starts every gzip stream and never starts a JSON document, so it
+// tells compressed entries apart from plain ones written before compression
+// was enabled (or while it is off)
+var gzipMagic = []byte{0x1f, 0x8b}
+
+var (
+	compressValues   = false
+	compressMinBytes = defaultCompressMinBytes
+)
+
+// InitCompression reads CACHE_COMPRESS and CACHE_COMPRESS_MIN_BYTES. Values
+// smaller than the threshold are stored as-is since gzip's overhead would
+// outweigh the saving.
+func InitCompression() {
+	compressValues, _ = strconv.ParseBool(os.Getenv("CACHE_COMPRESS"))
+
+	if raw := os.Getenv("CACHE_COMPRESS_MIN_BYTES"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid CACHE_COMPRESS_MIN_BYTES %q: must be a non-negative number of bytes", raw)
+		}
+		compressMinBytes = n
+	}
+}
+
+// encodeValue gzips data when compression is on and data is big enough
+func encodeValue(data []byte) []byte {
+	if !compressValues || len(data) < compressMinBytes {
+		return data
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return data
+	}
+	if err := zw.Close(); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
+
+// decodeValue undoes encodeValue. It reads compressed and plain entries alike,
+// whatever CACHE_COMPRESS is set to now.
+func decodeValue(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
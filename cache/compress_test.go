@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func useCompression(t *testing.T, minBytes int) {
+	t.Helper()
+	compressValues, compressMinBytes = true, minBytes
+	t.Cleanup(func() { compressValues, compressMinBytes = false, defaultCompressMinBytes })
+}
+
+func TestCompressedValueRoundTrip(t *testing.T) {
+	mr := useMiniredis(t)
+	useCompression(t, 100)
+
+	big := strings.Repeat("a long list payload ", 200)
+	StoreInCache("posts:list:big", big)
+	StoreInCache("posts:list:small", "short")
+
+	raw, err := mr.Get("posts:list:big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix([]byte(raw), gzipMagic) || len(raw) >= len(big) {
+		t.Errorf("large value stored uncompressed (%d bytes)", len(raw))
+	}
+	if raw, _ := mr.Get("posts:list:small"); bytes.HasPrefix([]byte(raw), gzipMagic) {
+		t.Error("value under the threshold was compressed")
+	}
+
+	for key, want := range map[string]string{"posts:list:big": big, "posts:list:small": "short"} {
+		var got string
+		if _, found := FetchFromCache(key, &got); !found || got != want {
+			t.Errorf("%s: fetched %d bytes (found %v), want %d", key, len(got), found, len(want))
+		}
+	}
+}
+
+func TestCompressedReadsPlainEntries(t *testing.T) {
+	mr := useMiniredis(t)
+	// Written before compression was turned on
+	StoreInCache("posts:list:old", strings.Repeat("x", 2000))
+	useCompression(t, 100)
+
+	var got string
+	if _, found := FetchFromCache("posts:list:old", &got); !found || len(got) != 2000 {
+		t.Errorf("plain entry not readable with compression on: found %v, %d bytes", found, len(got))
+	}
+
+	// And a compressed entry stays readable after compression is turned off
+	StoreInCache("posts:list:new", strings.Repeat("y", 2000))
+	compressValues = false
+	if raw, _ := mr.Get("posts:list:new"); !bytes.HasPrefix([]byte(raw), gzipMagic) {
+		t.Fatal("entry was not compressed")
+	}
+	if _, found := FetchFromCache("posts:list:new", &got); !found || got != strings.Repeat("y", 2000) {
+		t.Errorf("compressed entry not readable with compression off: found %v", found)
+	}
+}
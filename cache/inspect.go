@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// ErrForeignKey is returned for keys outside the namespaces this service owns
+var ErrForeignKey = errors.New("key is not a post, list or stale cache key")
+
+// Entry is a cached value as stored, along with its remaining lifetime
+type Entry struct {
+	Key      string          `json:"key"`
+	CachedAt *time.Time      `json:"cachedAt,omitempty"`
+	TTL      time.Duration   `json:"-"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// Inspect returns the entry stored at key. Only post and list keys can be
+// read, so the endpoint can't be used to peek at anything else sharing the
+// Redis instance.
+func Inspect(key string) (Entry, bool, error) {
+	if redisClient == nil {
+		return Entry{}, false, errors.New("Redis is not connected")
+	}
+	if !ownedKey(key) {
+		return Entry{}, false, ErrForeignKey
+	}
+
+	data, err := redisClient.Get(key).Bytes()
+	if err == redis.Nil {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, err
+	}
+	if data, err = decodeValue(data); err != nil {
+		return Entry{}, false, err
+	}
+	ttl, err := redisClient.TTL(key).Result()
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	entry := Entry{Key: key, TTL: ttl, Value: data}
+	var wrapped cacheEntry
+	if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.Value != nil {
+		entry.CachedAt = &wrapped.CachedAt
+		entry.Value = wrapped.Value
+	}
+	return entry, true, nil
+}
+
+func ownedKey(key string) bool {
+	for _, prefix := range []string{postCachePrefix, postsListPrefix, stalePostPrefix} {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScanPostIDs walks the cached post keys with SCAN, returning the ids in one
+// step and the cursor for the next; a zero cursor means the scan is done
+func ScanPostIDs(cursor uint64, count int64) ([]int, uint64, error) {
+	if redisClient == nil {
+		return nil, 0, errors.New("Redis is not connected")
+	}
+	keys, next, err := redisClient.Scan(cursor, postCachePrefix+"*", count).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]int, 0, len(keys))
+	for _, key := range keys {
+		if id, err := strconv.Atoi(strings.TrimPrefix(key, postCachePrefix)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, next, nil
+}
+
+// DropPost removes only the post's own entry, leaving list pages alone
+func DropPost(id int) error {
+	if redisClient == nil {
+		return nil
+	}
+	return redisClient.Del(BuildPostKey(id)).Err()
+}
+
+// purgeBatch is the SCAN COUNT hint and the most keys removed per DEL
+const purgeBatch = 500
+
+// Purge deletes every key matching a Redis glob pattern and returns how many
+// were removed. The pattern must start with one of the service's own prefixes
+// (e.g. posts:list:*), so a purge can never reach other data in the instance.
+func Purge(pattern string) (int64, error) {
+	if redisClient == nil {
+		return 0, errors.New("Redis is not connected")
+	}
+	if !ownedKey(pattern) {
+		return 0, ErrForeignKey
+	}
+
+	var removed int64
+	var cursor uint64
+	for {
+		keys, next, err := redisClient.Scan(cursor, pattern, purgeBatch).Result()
+		if err != nil {
+			return removed, err
+		}
+		if len(keys) > 0 {
+			n, err := redisClient.Del(keys...).Result()
+			removed += n
+			if err != nil {
+				return removed, err
+			}
+		}
+		if next == 0 {
+			return removed, nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+const (
+	recentPrefix = "recent:"
+	// RecentViewsCap is how many post ids each client's history keeps
+	RecentViewsCap = 20
+	// recentViewsTTL drops a client's history after a week without reads
+	recentViewsTTL = 7 * 24 * time.Hour
+)
+
+func buildRecentKey(client string) string {
+	return recentPrefix + client
+}
+
+// RecordView moves id to the front of the client's recently viewed list,
+// trimming the list to RecentViewsCap and renewing its TTL
+func RecordView(client string, id int) error {
+	if redisClient == nil {
+		return nil
+	}
+	key := buildRecentKey(client)
+	member := strconv.Itoa(id)
+
+	pipe := redisClient.TxPipeline()
+	pipe.LRem(key, 0, member)
+	pipe.LPush(key, member)
+	pipe.LTrim(key, 0, RecentViewsCap-1)
+	pipe.Expire(key, recentViewsTTL)
+	_, err := pipe.Exec()
+	return err
+}
+
+// RecentViews returns the client's recently viewed post ids, most recent first
+func RecentViews(client string) ([]int, error) {
+	if redisClient == nil {
+		return nil, errors.New("Redis is not connected")
+	}
+	members, err := redisClient.LRange(buildRecentKey(client), 0, RecentViewsCap-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.Atoi(m); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
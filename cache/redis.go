@@ -3,50 +3,125 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"go-server/events"
 	"go-server/models"
+	"go-server/utils"
 	"log"
+	"math/rand"
 	"os"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis"
 )
 
-type Post struct {
-	ID   int    `json:"id"`
-	Body string `json:"body"`
-}
-
 var (
 	redisClient *redis.Client
 	ctx         = context.Background()
+	// now stamps cache entries and measures their age; tests move it forward
+	now = time.Now
 )
 
 const (
 	postCachePrefix = "post:"
-	allPostsKey     = "all_posts"
-	cacheDuration   = 10 * time.Minute
+	postsListPrefix = "posts:list:"
+	stalePostPrefix = "stale:post:"
+	defaultCacheTTL = 10 * time.Minute
+	// Last-known-good copies outlive the regular entry so they're still
+	// around when MongoDB goes down
+	staleCacheDuration = 24 * time.Hour
+	poolSize           = 50
+	minIdleConns       = 10
+
+	// Match go-redis's own default; a proxy dropping idle sockets sooner
+	// needs this lowered
+	defaultIdleTimeout = 5 * time.Minute
 )
 
-func InitRedis() {
-	redisURL, redisPassword, redisDB := getRedisConfig()
+// Settings is the effective Redis configuration, safe to expose to operators
+// once the password has been redacted
+type Settings struct {
+	Addr          string  `json:"addr"`
+	Password      string  `json:"password"`
+	DB            int     `json:"db"`
+	PoolSize      int     `json:"poolSize"`
+	MinIdleConns  int     `json:"minIdleConns"`
+	IdleTimeout   string  `json:"idleTimeout"`
+	MaxConnAge    string  `json:"maxConnAge"`
+	CacheDuration string  `json:"cacheDuration"`
+	TTLJitter     float64 `json:"ttlJitter"`
+	MaxValueBytes int     `json:"maxValueBytes"`
+	WritePolicy   string  `json:"writePolicy"`
+	Compress      bool    `json:"compress"`
+	CompressMin   int     `json:"compressMinBytes"`
+	Enabled       bool    `json:"enabled"`
+}
 
-	redisClient = redis.NewClient(&redis.Options{
-		Addr:         redisURL,
-		Password:     redisPassword,
-		DB:           redisDB,
-		PoolSize:     50,
-		MinIdleConns: 10,
-	})
+// InitRedis connects to Redis. A failed connection leaves caching disabled
+// and is returned so startup can report it.
+func InitRedis() error {
+	opts := redisOptions()
+	redisURL := opts.Addr
+	log.Printf("Connecting to Redis at %s", utils.RedactURI(redisURL))
+
+	redisClient = redis.NewClient(opts)
 
 	if err := testRedisConnection(); err != nil {
+		err = errors.New(utils.RedactURIIn(err.Error(), redisURL))
 		log.Printf("Warning: Redis connection failed: %v", err)
 		log.Println("Continuing without Redis cache")
+		redisClient.Close()
 		redisClient = nil
-	} else {
-		fmt.Println("Connected to Redis!")
+		return err
+	}
+	fmt.Println("Connected to Redis!")
+	return nil
+}
+
+// Use points the package at client; nil leaves it without a cache. Tests use
+// it to swap in an in-memory Redis.
+func Use(client *redis.Client) {
+	redisClient = client
+}
+
+// redisOptions builds the client options from the environment.
+// REDIS_IDLE_TIMEOUT and REDIS_MAX_CONN_AGE mirror the Mongo pool's idle
+// tuning; a zero REDIS_MAX_CONN_AGE keeps connections indefinitely.
+func redisOptions() *redis.Options {
+	redisURL, redisPassword, redisDB := getRedisConfig()
+	idleTimeout, maxConnAge := getRedisConnLifetimes()
+	return &redis.Options{
+		Addr:         redisURL,
+		Password:     redisPassword,
+		DB:           redisDB,
+		PoolSize:     poolSize,
+		MinIdleConns: minIdleConns,
+		IdleTimeout:  idleTimeout,
+		MaxConnAge:   maxConnAge,
+	}
+}
+
+func getRedisConnLifetimes() (idleTimeout, maxConnAge time.Duration) {
+	idleTimeout = defaultIdleTimeout
+	if raw := os.Getenv("REDIS_IDLE_TIMEOUT"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid REDIS_IDLE_TIMEOUT %q: must be a positive duration", raw)
+		}
+		idleTimeout = d
+	}
+	if raw := os.Getenv("REDIS_MAX_CONN_AGE"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid REDIS_MAX_CONN_AGE %q: must be a non-negative duration", raw)
+		}
+		maxConnAge = d
 	}
+	return idleTimeout, maxConnAge
 }
 
 func getRedisConfig() (string, string, int) {
@@ -63,89 +138,427 @@ func getRedisConfig() (string, string, int) {
 	return redisURL, redisPassword, redisDB
 }
 
+// cacheTTLNanos is how long regular post and list entries live. It is
+// atomic because SIGHUP can change it while requests are in flight.
+var cacheTTLNanos atomic.Int64
+
+func init() {
+	cacheTTLNanos.Store(int64(defaultCacheTTL))
+}
+
+func cacheTTL() time.Duration {
+	return time.Duration(cacheTTLNanos.Load())
+}
+
+// InitCacheTTL reads CACHE_TTL. Zero or negative values are rejected rather
+// than risk entries that never expire; to stop caching, use
+// CACHE_DISABLED_ROUTES instead.
+func InitCacheTTL() {
+	if err := LoadCacheTTL(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// LoadCacheTTL applies CACHE_TTL, falling back to the default when unset.
+// On a bad value the current TTL is kept and the error returned.
+func LoadCacheTTL() error {
+	ttl := defaultCacheTTL
+	if raw := os.Getenv("CACHE_TTL"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("Invalid CACHE_TTL %q: must be a positive duration (use CACHE_DISABLED_ROUTES to disable caching)", raw)
+		}
+		ttl = parsed
+	}
+	cacheTTLNanos.Store(int64(ttl))
+	return nil
+}
+
+// maxValueBytes skips caching any entry larger than this once marshaled;
+// 0 means no limit
+var maxValueBytes int
+
+// InitMaxValueSize reads MAX_CACHE_VALUE_BYTES, the largest entry worth
+// caching. Oversized values are simply not stored and read back as misses.
+func InitMaxValueSize() {
+	raw := os.Getenv("MAX_CACHE_VALUE_BYTES")
+	if raw == "" {
+		return
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		log.Fatalf("Invalid MAX_CACHE_VALUE_BYTES %q: must be a positive number of bytes", raw)
+	}
+	maxValueBytes = n
+}
+
+// ttlJitter spreads expirations by up to this fraction of the TTL either way
+var ttlJitter float64
+
+// InitTTLJitter reads CACHE_TTL_JITTER, a percentage (0-50) by which stored
+// TTLs are randomly varied so keys written together don't expire together
+func InitTTLJitter() {
+	raw := os.Getenv("CACHE_TTL_JITTER")
+	if raw == "" {
+		return
+	}
+	pct, err := strconv.Atoi(strings.TrimSuffix(raw, "%"))
+	if err != nil || pct < 0 || pct > 50 {
+		log.Fatalf("Invalid CACHE_TTL_JITTER %q: must be a percentage between 0 and 50", raw)
+	}
+	ttlJitter = float64(pct) / 100
+}
+
+// jitteredTTL returns ttl moved randomly within ±ttlJitter
+func jitteredTTL(ttl time.Duration) time.Duration {
+	if ttlJitter == 0 {
+		return ttl
+	}
+	spread := (rand.Float64()*2 - 1) * ttlJitter
+	return ttl + time.Duration(float64(ttl)*spread)
+}
+
+// Routes whose caching can be switched off with CACHE_DISABLED_ROUTES
+const (
+	RoutePost = "post"
+	RouteList = "list"
+)
+
+// disabledRoutes holds the routes operators opted out of caching
+var disabledRoutes = map[string]bool{}
+
+// InitRouteCaching reads CACHE_DISABLED_ROUTES, a comma-separated list of
+// routes (post, list) that should bypass the cache entirely
+func InitRouteCaching() {
+	disabledRoutes = map[string]bool{}
+	for _, route := range strings.Split(os.Getenv("CACHE_DISABLED_ROUTES"), ",") {
+		route = strings.TrimSpace(route)
+		switch route {
+		case "":
+		case RoutePost, RouteList:
+			disabledRoutes[route] = true
+			log.Printf("Caching disabled for route %q", route)
+		default:
+			log.Fatalf("Invalid CACHE_DISABLED_ROUTES entry %q: expected %q or %q", route, RoutePost, RouteList)
+		}
+	}
+}
+
+// Enabled reports whether a Redis connection is available
+func Enabled() bool {
+	return redisClient != nil
+}
+
+// EnabledFor reports whether the given route may read and write the cache
+func EnabledFor(route string) bool {
+	return Enabled() && !disabledRoutes[route]
+}
+
+func CurrentSettings() Settings {
+	opts := redisOptions()
+	return Settings{
+		Addr:          opts.Addr,
+		Password:      opts.Password,
+		DB:            opts.DB,
+		PoolSize:      opts.PoolSize,
+		MinIdleConns:  opts.MinIdleConns,
+		TTLJitter:     ttlJitter,
+		MaxValueBytes: maxValueBytes,
+		WritePolicy:   writePolicy,
+		Compress:      compressValues,
+		CompressMin:   compressMinBytes,
+		IdleTimeout:   opts.IdleTimeout.String(),
+		MaxConnAge:    opts.MaxConnAge.String(),
+		CacheDuration: cacheTTL().String(),
+		Enabled:       redisClient != nil,
+	}
+}
+
+// Ping checks that Redis is reachable
+func Ping(ctx context.Context) error {
+	if redisClient == nil {
+		return errors.New("Redis is not connected")
+	}
+	return redisClient.WithContext(ctx).Ping().Err()
+}
+
 func testRedisConnection() error {
 	_, err := redisClient.Ping().Result()
 	return err
 }
 
-func CachePost(post Post) {
+// cacheEntry wraps every cached value with the time it was written so reads
+// can report how stale they are
+type cacheEntry struct {
+	CachedAt time.Time       `json:"cachedAt"`
+	Value    json.RawMessage `json:"value"`
+}
+
+func CachePost(post models.Post) {
 	if redisClient == nil {
 		return
 	}
 	cacheKey := BuildPostKey(post.ID)
 	StoreInCache(cacheKey, post)
 }
-func GetCachedPost(id int) (Post, bool) {
+
+// GetCachedPost returns the cached post and how long ago it was cached
+func GetCachedPost(id int) (models.Post, time.Duration, bool) {
 	if redisClient == nil {
-		return Post{}, false
+		return models.Post{}, 0, false
 	}
 
-	var post Post
+	var post models.Post
 	cacheKey := BuildPostKey(id)
 
-	if found := FetchFromCache(cacheKey, &post); !found {
-		return Post{}, false
+	cachedAt, found := FetchFromCache(cacheKey, &post)
+	if !found {
+		return models.Post{}, 0, false
 	}
 
-	return post, true
+	return post, now().Sub(cachedAt), true
+}
+
+// Cache write policies, chosen with CACHE_WRITE_POLICY
+const (
+	// WritePolicyAround only invalidates on writes; the next read repopulates
+	WritePolicyAround = "around"
+	// WritePolicyThrough also stores the written post, saving that first miss
+	WritePolicyThrough = "through"
+)
+
+var writePolicy = WritePolicyAround
+
+// InitWritePolicy reads CACHE_WRITE_POLICY (around or through)
+func InitWritePolicy() {
+	raw := os.Getenv("CACHE_WRITE_POLICY")
+	switch raw {
+	case "":
+	case WritePolicyAround, WritePolicyThrough:
+		writePolicy = raw
+	default:
+		log.Fatalf("Invalid CACHE_WRITE_POLICY %q: must be %s or %s", raw, WritePolicyAround, WritePolicyThrough)
+	}
 }
+
+// SubscribeToPostEvents keeps the cache consistent by invalidating a post's
+// entries whenever it is created, updated or deleted. List pages are always
+// invalidated; with write-through the post's own entry is then refilled.
+func SubscribeToPostEvents() {
+	events.Subscribe(func(e events.Event) {
+		if writePolicy == WritePolicyThrough && e.Type != events.PostDeleted && EnabledFor(RoutePost) {
+			writeThrough(e)
+			return
+		}
+		InvalidatePostCache(e.PostID)
+	}, events.PostCreated, events.PostUpdated, events.PostDeleted)
+
+	// A deleted post must not come back as a stale fallback
+	events.Subscribe(func(e events.Event) {
+		if redisClient != nil {
+			redisClient.Del(buildStalePostKey(e.PostID))
+		}
+	}, events.PostDeleted)
+
+	events.Subscribe(func(e events.Event) {
+		InvalidatePostsCache(e.PostIDs)
+	}, events.PostsDeleted)
+}
+
+// writeThrough refills a post's entry from its event. An event without the
+// post (the publisher's re-read failed) only invalidates. Concurrent writes
+// can publish out of order, so an event older than the cached version leaves
+// the newer entry in place.
+func writeThrough(e events.Event) {
+	if e.Post.ID != e.PostID {
+		InvalidatePostCache(e.PostID)
+		return
+	}
+	if cached, _, found := GetCachedPost(e.PostID); found && cached.Version > e.Post.Version {
+		invalidateListCaches()
+		return
+	}
+	InvalidatePostCache(e.PostID)
+	CachePost(e.Post)
+}
+
+// InvalidatePostCache drops the post's own entry and every cached list page,
+// since any list may contain the post
 func InvalidatePostCache(id int) {
 	if redisClient == nil {
 		return
 	}
 
-	keys := []string{BuildPostKey(id), allPostsKey}
+	if err := redisClient.Del(BuildPostKey(id)).Err(); err != nil {
+		log.Printf("Error invalidating cache: %v", err)
+	}
+	invalidateListCaches()
+}
+
+// InvalidatePostsCache drops the entries and stale fallbacks of many deleted
+// posts with one DEL, then the list pages once rather than once per post
+func InvalidatePostsCache(ids []int) {
+	if redisClient == nil || len(ids) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, 2*len(ids))
+	for _, id := range ids {
+		keys = append(keys, BuildPostKey(id), buildStalePostKey(id))
+	}
 	if err := redisClient.Del(keys...).Err(); err != nil {
 		log.Printf("Error invalidating cache: %v", err)
 	}
+	invalidateListCaches()
+}
+
+// invalidateListCaches deletes every key under the list prefix, using SCAN so
+// Redis isn't blocked the way KEYS would block it
+func invalidateListCaches() {
+	var cursor uint64
+	for {
+		keys, next, err := redisClient.Scan(cursor, postsListPrefix+"*", 100).Result()
+		if err != nil {
+			log.Printf("Error scanning list cache keys: %v", err)
+			return
+		}
+		if len(keys) > 0 {
+			if err := redisClient.Del(keys...).Err(); err != nil {
+				log.Printf("Error invalidating list cache: %v", err)
+			}
+		}
+		if next == 0 {
+			return
+		}
+		cursor = next
+	}
 }
 
-func CacheAllPosts(posts []models.Post) {
+// CachePostsList stores a rendered GET /posts response. Caching the encoded
+// page rather than the posts keeps a hit byte-for-byte identical to the miss
+// that filled it, totals and paging included.
+func CachePostsList(key string, page json.RawMessage) {
 	if redisClient == nil {
 		return
 	}
-	StoreInCache(allPostsKey, posts)
+	StoreInCache(key, page)
 }
 
-func GetCachedAllPosts() ([]Post, bool) {
+// GetCachedPostsList returns the cached list page and how long ago it was
+// cached
+func GetCachedPostsList(key string) (json.RawMessage, time.Duration, bool) {
 	if redisClient == nil {
-		return nil, false
+		return nil, 0, false
 	}
 
-	var posts []Post
-	if found := FetchFromCache(allPostsKey, &posts); !found {
-		return nil, false
+	var page json.RawMessage
+	cachedAt, found := FetchFromCache(key, &page)
+	if !found {
+		return nil, 0, false
 	}
+	// Older entries hold a bare array of posts; let them miss and be replaced
+	if len(page) == 0 || page[0] != '{' {
+		return nil, 0, false
+	}
+
+	return page, now().Sub(cachedAt), true
+}
 
-	return posts, true
+// BuildPostsListKey namespaces a canonical list query string
+func BuildPostsListKey(query string) string {
+	return postsListPrefix + query
 }
 
 func BuildPostKey(id int) string {
 	return fmt.Sprintf("%s%d", postCachePrefix, id)
 }
 
+func buildStalePostKey(id int) string {
+	return fmt.Sprintf("%s%d", stalePostPrefix, id)
+}
+
+// CacheStalePost keeps a long-lived last-known-good copy of a post, which
+// isn't touched by regular invalidation
+func CacheStalePost(post models.Post) {
+	if redisClient == nil {
+		return
+	}
+	storeInCache(buildStalePostKey(post.ID), post, staleCacheDuration)
+}
+
+// GetStalePost returns the last-known-good copy of a post and its age
+func GetStalePost(id int) (models.Post, time.Duration, bool) {
+	if redisClient == nil {
+		return models.Post{}, 0, false
+	}
+
+	var post models.Post
+	cachedAt, found := FetchFromCache(buildStalePostKey(id), &post)
+	if !found {
+		return models.Post{}, 0, false
+	}
+	return post, now().Sub(cachedAt), true
+}
+
 func StoreInCache(key string, value interface{}) {
-	data, err := json.Marshal(value)
+	storeInCache(key, value, cacheTTL())
+}
+
+func storeInCache(key string, value interface{}, ttl time.Duration) {
+	raw, err := json.Marshal(value)
 	if err != nil {
 		log.Printf("Error marshaling for cache [%s]: %v", key, err)
 		return
 	}
 
-	if err := redisClient.Set(key, data, cacheDuration).Err(); err != nil {
-		log.Printf("Error caching key [%s]: %v", key, err)
+	data, err := json.Marshal(cacheEntry{CachedAt: now(), Value: raw})
+	if err != nil {
+		log.Printf("Error marshaling for cache [%s]: %v", key, err)
+		return
+	}
+	// The size limit applies to what Redis actually holds
+	data = encodeValue(data)
+
+	if maxValueBytes > 0 && len(data) > maxValueBytes {
+		log.Printf("Not caching [%s]: %d bytes exceeds MAX_CACHE_VALUE_BYTES (%d)", key, len(data), maxValueBytes)
+		return
 	}
 
+	if err := redisClient.Set(key, data, jitteredTTL(ttl)).Err(); err != nil {
+		log.Printf("Error caching key [%s]: %v", key, err)
+	}
 }
-func FetchFromCache(key string, target interface{}) bool {
+
+// FetchFromCache decodes the value stored at key into target and returns
+// when it was written
+func FetchFromCache(key string, target interface{}) (time.Time, bool) {
 	data, err := redisClient.Get(key).Bytes()
 	if err != nil {
-		return false
+		return time.Time{}, false
+	}
+	if data, err = decodeValue(data); err != nil {
+		log.Printf("Error decompressing cached data [%s]: %v", key, err)
+		return time.Time{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Value == nil {
+		// Entries written before timestamps were tracked hold the bare value
+		entry = cacheEntry{CachedAt: now(), Value: data}
 	}
 
-	if err := json.Unmarshal(data, target); err != nil {
+	if err := json.Unmarshal(entry.Value, target); err != nil {
 		log.Printf("Error unmarshaling cached data [%s]: %v", key, err)
-		return false
+		return time.Time{}, false
 	}
 
-	return true
+	return entry.CachedAt, true
+}
+
+// Close shuts the Redis connection pool
+func Close(context.Context) error {
+	if redisClient == nil {
+		return nil
+	}
+	return redisClient.Close()
 }
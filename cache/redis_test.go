@@ -0,0 +1,290 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"go-server/events"
+	"go-server/models"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+// useMiniredis points the package at an in-memory Redis for one test
+func useMiniredis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	redisClient = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		redisClient.Close()
+		redisClient = nil
+	})
+	return mr
+}
+
+var subscribeOnce sync.Once
+
+func useWriteThrough(t *testing.T) {
+	t.Helper()
+	subscribeOnce.Do(SubscribeToPostEvents)
+	writePolicy = WritePolicyThrough
+	t.Cleanup(func() { writePolicy = WritePolicyAround })
+}
+
+func TestWriteThroughCachesPublishedPost(t *testing.T) {
+	mr := useMiniredis(t)
+	useWriteThrough(t)
+	mr.Set(postsListPrefix+"page", "{}")
+
+	events.Publish(events.Event{Type: events.PostUpdated, PostID: 7, Post: models.Post{ID: 7, Body: "hello", Version: 2}})
+
+	cached, _, found := GetCachedPost(7)
+	if !found {
+		t.Fatal("post 7 not cached after write-through")
+	}
+	if cached.Body != "hello" || cached.Version != 2 {
+		t.Errorf("cached %+v, want body hello at version 2", cached)
+	}
+	if mr.Exists(postsListPrefix + "page") {
+		t.Error("list page survived a post update")
+	}
+}
+
+func TestWriteThroughWithoutPostOnlyInvalidates(t *testing.T) {
+	mr := useMiniredis(t)
+	useWriteThrough(t)
+	CachePost(models.Post{ID: 7, Body: "old", Version: 1})
+
+	// A publisher whose re-read failed sends the id with a zero-value post
+	events.Publish(events.Event{Type: events.PostUpdated, PostID: 7})
+
+	if _, _, found := GetCachedPost(7); found {
+		t.Error("post 7 still cached after an update without the post")
+	}
+	if mr.Exists(BuildPostKey(0)) {
+		t.Error("zero-value post was written through as post:0")
+	}
+}
+
+func TestWriteThroughKeepsNewerVersion(t *testing.T) {
+	useMiniredis(t)
+	useWriteThrough(t)
+
+	// Two concurrent updates whose events arrive newest first
+	events.Publish(events.Event{Type: events.PostUpdated, PostID: 7, Post: models.Post{ID: 7, Body: "v3", Version: 3}})
+	events.Publish(events.Event{Type: events.PostUpdated, PostID: 7, Post: models.Post{ID: 7, Body: "v2", Version: 2}})
+
+	cached, _, found := GetCachedPost(7)
+	if !found {
+		t.Fatal("post 7 not cached")
+	}
+	if cached.Version != 3 {
+		t.Errorf("cached version %d, want 3", cached.Version)
+	}
+}
+
+func TestWriteThroughSkipsDeletedPosts(t *testing.T) {
+	useMiniredis(t)
+	useWriteThrough(t)
+	CachePost(models.Post{ID: 7, Body: "gone", Version: 1})
+
+	events.Publish(events.Event{Type: events.PostDeleted, PostID: 7, Post: models.Post{ID: 7, Body: "gone", Version: 2}})
+
+	if _, _, found := GetCachedPost(7); found {
+		t.Error("deleted post was written back to the cache")
+	}
+}
+
+func TestBulkDeleteScansListsOnce(t *testing.T) {
+	mr := useMiniredis(t)
+	subscribeOnce.Do(SubscribeToPostEvents)
+	ids := []int{1, 2, 3, 4, 5}
+	for _, id := range ids {
+		mr.Set(BuildPostKey(id), "{}")
+		mr.Set(buildStalePostKey(id), "{}")
+	}
+	mr.Set(BuildPostKey(6), "{}")
+	mr.Set(postsListPrefix+"page", "{}")
+
+	before := mr.CommandCount()
+	events.Publish(events.Event{Type: events.PostsDeleted, PostIDs: ids})
+
+	// One DEL for the posts, one SCAN and one DEL for the list pages
+	if n := mr.CommandCount() - before; n != 3 {
+		t.Errorf("bulk invalidation sent %d commands, want 3", n)
+	}
+	if keys := mr.Keys(); len(keys) != 1 || keys[0] != BuildPostKey(6) {
+		t.Errorf("keys left %v, want only post 6", keys)
+	}
+}
+
+func TestCacheAgeGrowsOnRepeatedHits(t *testing.T) {
+	useMiniredis(t)
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+
+	CachePost(models.Post{ID: 1, Body: "b", Version: 1})
+	listKey := BuildPostsListKey("limit=10")
+	CachePostsList(listKey, json.RawMessage(`{"posts":[]}`))
+
+	for _, want := range []time.Duration{0, 3 * time.Second, 65 * time.Second} {
+		clock = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(want)
+		if _, age, found := GetCachedPost(1); !found || age != want {
+			t.Errorf("post: age %s (found %v), want %s", age, found, want)
+		}
+		if _, age, found := GetCachedPostsList(listKey); !found || age != want {
+			t.Errorf("list: age %s (found %v), want %s", age, found, want)
+		}
+	}
+}
+
+// runsFatal reports whether fn stops the process. It reruns the calling test
+// in a child process with env added, where fn is expected to call log.Fatal.
+func runsFatal(t *testing.T, fn func(), env ...string) bool {
+	t.Helper()
+	if os.Getenv("GO_TEST_FATAL_CHILD") == "1" {
+		fn()
+		os.Exit(0)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$")
+	cmd.Env = append(append(os.Environ(), "GO_TEST_FATAL_CHILD=1"), env...)
+	out, err := cmd.CombinedOutput()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		t.Fatalf("running child: %v", err)
+	}
+	t.Logf("child output:\n%s", out)
+	return err != nil
+}
+
+func TestRedisOptionsConnLifetimes(t *testing.T) {
+	t.Setenv("REDIS_IDLE_TIMEOUT", "")
+	t.Setenv("REDIS_MAX_CONN_AGE", "")
+	opts := redisOptions()
+	if opts.IdleTimeout != defaultIdleTimeout || opts.MaxConnAge != 0 {
+		t.Errorf("defaults: idle %s, max age %s; want %s and unlimited", opts.IdleTimeout, opts.MaxConnAge, defaultIdleTimeout)
+	}
+
+	t.Setenv("REDIS_IDLE_TIMEOUT", "90s")
+	t.Setenv("REDIS_MAX_CONN_AGE", "30m")
+	opts = redisOptions()
+	if opts.IdleTimeout != 90*time.Second || opts.MaxConnAge != 30*time.Minute {
+		t.Errorf("idle %s, max age %s; want 1m30s and 30m", opts.IdleTimeout, opts.MaxConnAge)
+	}
+}
+
+func TestRedisOptionsRejectBadLifetimes(t *testing.T) {
+	for _, env := range []string{"REDIS_IDLE_TIMEOUT=0s", "REDIS_IDLE_TIMEOUT=soon", "REDIS_MAX_CONN_AGE=-1m"} {
+		t.Run(env, func(t *testing.T) {
+			if !runsFatal(t, func() { redisOptions() }, env) {
+				t.Errorf("%s accepted", env)
+			}
+		})
+	}
+}
+
+func TestStoredTTLsVaryWithinJitterBand(t *testing.T) {
+	mr := useMiniredis(t)
+	defer func(j float64) { ttlJitter = j }(ttlJitter)
+	t.Setenv("CACHE_TTL_JITTER", "20%")
+	InitTTLJitter()
+
+	base := cacheTTL()
+	low, high := time.Duration(float64(base)*0.8), time.Duration(float64(base)*1.2)
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 200; i++ {
+		key := BuildPostKey(i)
+		StoreInCache(key, models.Post{ID: i})
+		ttl := mr.TTL(key)
+		if ttl < low || ttl > high {
+			t.Fatalf("TTL %s outside %s..%s", ttl, low, high)
+		}
+		seen[ttl.Truncate(time.Second)] = true
+	}
+	if len(seen) < 10 {
+		t.Errorf("only %d distinct TTLs across 200 keys, want them spread out", len(seen))
+	}
+}
+
+func TestStoredTTLExactWithoutJitter(t *testing.T) {
+	mr := useMiniredis(t)
+	defer func(j float64) { ttlJitter = j }(ttlJitter)
+	ttlJitter = 0
+
+	StoreInCache(BuildPostKey(1), models.Post{ID: 1})
+	if ttl := mr.TTL(BuildPostKey(1)); ttl != cacheTTL() {
+		t.Errorf("TTL %s, want exactly %s", ttl, cacheTTL())
+	}
+}
+
+func TestStartupFailsOnNonPositiveTTL(t *testing.T) {
+	for _, raw := range []string{"0", "0s", "-10m"} {
+		t.Run(raw, func(t *testing.T) {
+			if !runsFatal(t, InitCacheTTL, "CACHE_TTL="+raw) {
+				t.Errorf("CACHE_TTL=%s accepted at startup", raw)
+			}
+		})
+	}
+}
+
+func TestLoadCacheTTLKeepsTTLOnBadValue(t *testing.T) {
+	defer cacheTTLNanos.Store(cacheTTLNanos.Load())
+
+	t.Setenv("CACHE_TTL", "90s")
+	if err := LoadCacheTTL(); err != nil || cacheTTL() != 90*time.Second {
+		t.Fatalf("TTL %s, err %v; want 1m30s", cacheTTL(), err)
+	}
+	t.Setenv("CACHE_TTL", "0")
+	if err := LoadCacheTTL(); err == nil {
+		t.Error("zero CACHE_TTL accepted on reload")
+	}
+	if cacheTTL() != 90*time.Second {
+		t.Errorf("TTL changed to %s by a rejected value", cacheTTL())
+	}
+}
+
+func TestOversizedValueNotStored(t *testing.T) {
+	mr := useMiniredis(t)
+	defer func(n int) { maxValueBytes = n }(maxValueBytes)
+	maxValueBytes = 400
+
+	CachePost(models.Post{ID: 1, Body: "small", Version: 1})
+	CachePost(models.Post{ID: 2, Body: strings.Repeat("x", 500), Version: 1})
+
+	if !mr.Exists(BuildPostKey(1)) {
+		t.Error("value under the limit not stored")
+	}
+	if mr.Exists(BuildPostKey(2)) {
+		t.Error("value over MAX_CACHE_VALUE_BYTES stored")
+	}
+	if _, _, found := GetCachedPost(2); found {
+		t.Error("oversized post read back as a hit")
+	}
+}
+
+func TestRecordViewCapsHistory(t *testing.T) {
+	mr := useMiniredis(t)
+	for id := 1; id <= RecentViewsCap+5; id++ {
+		if err := RecordView("client", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := RecentViews("client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != RecentViewsCap || ids[0] != RecentViewsCap+5 || ids[len(ids)-1] != 6 {
+		t.Errorf("history %v, want the last %d ids newest first", ids, RecentViewsCap)
+	}
+	if ttl := mr.TTL(buildRecentKey("client")); ttl != recentViewsTTL {
+		t.Errorf("TTL %v, want %v", ttl, recentViewsTTL)
+	}
+}
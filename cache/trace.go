@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+type traceKey struct{}
+
+// trace collects the cache decisions made while serving one request
+type trace struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+// WithTrace returns a context that records every Trace call made with it or
+// any context derived from it
+func WithTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, traceKey{}, new(trace))
+}
+
+// Trace records that layer was consulted with the given outcome, e.g.
+// ("redis", "miss"). It does nothing when ctx carries no trace.
+func Trace(ctx context.Context, layer, outcome string) {
+	t, ok := ctx.Value(traceKey{}).(*trace)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	t.steps = append(t.steps, layer+"="+outcome)
+	t.mu.Unlock()
+}
+
+// TraceString renders the recorded decisions in order, or "" when there are
+// none
+func TraceString(ctx context.Context) string {
+	t, ok := ctx.Value(traceKey{}).(*trace)
+	if !ok {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.Join(t.steps, ", ")
+}
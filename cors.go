@@ -0,0 +1,88 @@
+package main
+
+import (
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/cors"
+)
+
+var defaultCORSOrigins = []string{"http://localhost:3000", "http://localhost:3001"}
+
+// Ways to resolve CORS_ALLOWED_ORIGINS=* together with credentials, which
+// browsers refuse: a credentialed response must name one origin
+const (
+	corsWildcardFail    = "fail"
+	corsWildcardReflect = "reflect"
+)
+
+// corsOptions builds the CORS configuration from CORS_ALLOWED_ORIGINS,
+// CORS_ALLOW_CREDENTIALS and CORS_MAX_AGE. A wildcard origin with credentials
+// stops the server unless CORS_WILDCARD_CREDENTIALS=reflect, in which case
+// every request's own origin is echoed back instead of "*".
+func corsOptions() cors.Options {
+	opts := cors.Options{
+		AllowedOrigins:   corsAllowedOrigins(),
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowCredentials: corsAllowCredentials(),
+		MaxAge:           corsMaxAge(),
+	}
+	if !opts.AllowCredentials || !slices.Contains(opts.AllowedOrigins, "*") {
+		return opts
+	}
+
+	switch mode := os.Getenv("CORS_WILDCARD_CREDENTIALS"); mode {
+	case "", corsWildcardFail:
+		log.Fatalf("CORS_ALLOWED_ORIGINS=* cannot be combined with credentials: browsers reject it. " +
+			"List the origins explicitly, set CORS_ALLOW_CREDENTIALS=false, or set CORS_WILDCARD_CREDENTIALS=reflect to allow every origin")
+	case corsWildcardReflect:
+		log.Println("CORS: wildcard origin with credentials, reflecting each request's Origin")
+		opts.AllowedOrigins = nil
+		opts.AllowOriginFunc = func(string) bool { return true }
+	default:
+		log.Fatalf("Invalid CORS_WILDCARD_CREDENTIALS %q: must be %s or %s", mode, corsWildcardFail, corsWildcardReflect)
+	}
+	return opts
+}
+
+// corsAllowedOrigins reads CORS_ALLOWED_ORIGINS, a comma-separated list
+func corsAllowedOrigins() []string {
+	raw := os.Getenv("CORS_ALLOWED_ORIGINS")
+	if raw == "" {
+		return defaultCORSOrigins
+	}
+	var origins []string
+	for _, origin := range strings.Split(raw, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// corsAllowCredentials reads CORS_ALLOW_CREDENTIALS (default true)
+func corsAllowCredentials() bool {
+	allow, err := strconv.ParseBool(os.Getenv("CORS_ALLOW_CREDENTIALS"))
+	if err != nil {
+		return true
+	}
+	return allow
+}
+
+// corsMaxAge reads CORS_MAX_AGE, the number of seconds browsers may cache a
+// preflight response. Unset means browsers fall back to their own default.
+func corsMaxAge() int {
+	raw := os.Getenv("CORS_MAX_AGE")
+	if raw == "" {
+		return 0
+	}
+	maxAge, err := strconv.Atoi(raw)
+	if err != nil || maxAge < 0 {
+		log.Fatalf("Invalid CORS_MAX_AGE %q: must be a non-negative number of seconds", raw)
+	}
+	return maxAge
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/rs/cors"
+)
+
+func preflight(t *testing.T) *httptest.ResponseRecorder {
+	t.Helper()
+	h := cors.New(corsOptions()).Handler(http.NotFoundHandler())
+	req := httptest.NewRequest(http.MethodOptions, "/posts", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSMaxAgeOnPreflight(t *testing.T) {
+	t.Setenv("CORS_MAX_AGE", "600")
+	rec := preflight(t)
+	if got := rec.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Access-Control-Max-Age = %q, want 600", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the request's origin", got)
+	}
+}
+
+func TestCORSMaxAgeUnset(t *testing.T) {
+	t.Setenv("CORS_MAX_AGE", "")
+	if got := preflight(t).Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("Access-Control-Max-Age = %q without CORS_MAX_AGE, want none", got)
+	}
+}
+
+// runsFatal reports whether fn stops the process. It reruns the calling test
+// in a child process with env added, where fn is expected to call log.Fatal.
+func runsFatal(t *testing.T, fn func(), env ...string) bool {
+	t.Helper()
+	if os.Getenv("GO_TEST_FATAL_CHILD") == "1" {
+		fn()
+		os.Exit(0)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$")
+	cmd.Env = append(append(os.Environ(), "GO_TEST_FATAL_CHILD=1"), env...)
+	out, err := cmd.CombinedOutput()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		t.Fatalf("running child: %v", err)
+	}
+	t.Logf("child output:\n%s", out)
+	return err != nil
+}
+
+func TestCORSMaxAgeRejectsNegative(t *testing.T) {
+	if !runsFatal(t, func() { corsMaxAge() }, "CORS_MAX_AGE=-5") {
+		t.Error("negative CORS_MAX_AGE accepted")
+	}
+}
+
+func TestCORSWildcardWithCredentialsFails(t *testing.T) {
+	if !runsFatal(t, func() { corsOptions() }, "CORS_ALLOWED_ORIGINS=*", "CORS_ALLOW_CREDENTIALS=true", "CORS_WILDCARD_CREDENTIALS=") {
+		t.Error("wildcard origin with credentials accepted")
+	}
+}
+
+func TestCORSWildcardWithCredentialsReflects(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("CORS_WILDCARD_CREDENTIALS", "reflect")
+
+	h := cors.New(corsOptions()).Handler(http.NotFoundHandler())
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.Header.Set("Origin", "https://app.example")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the request origin", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want true", got)
+	}
+}
+
+func TestCORSWildcardWithoutCredentials(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "false")
+	if opts := corsOptions(); opts.AllowCredentials || len(opts.AllowedOrigins) != 1 || opts.AllowedOrigins[0] != "*" {
+		t.Errorf("options %+v, want a plain wildcard without credentials", opts)
+	}
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ensureCollection creates the named collection when it doesn't exist yet,
+// e.g. on a fresh database, so index creation and stats don't depend on the
+// first insert having happened
+func ensureCollection(ctx context.Context, database *mongo.Database, name string) error {
+	names, err := database.ListCollectionNames(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+	if len(names) > 0 {
+		return nil
+	}
+
+	log.Printf("Collection %s.%s does not exist, creating it", database.Name(), name)
+	if err := database.CreateCollection(ctx, name); err != nil {
+		// Another instance may have created it in the meantime
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceExists" {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
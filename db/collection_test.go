@@ -0,0 +1,43 @@
+package db_test
+
+import (
+	"context"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestUseCreatesMissingCollection(t *testing.T) {
+	// dbtest.Connect boots against a brand new database with no collections
+	dbtest.Connect(t)
+	ctx := context.Background()
+	database := db.PostCol.Database()
+
+	names, err := database.ListCollectionNames(ctx, bson.M{"name": db.PostCol.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Fatalf("collection %s was not created: %v", db.PostCol.Name(), names)
+	}
+
+	// Running it again against the existing collection is a no-op
+	if err := db.Use(ctx, db.Client, database); err != nil {
+		t.Fatalf("second Use: %v", err)
+	}
+
+	count, err := db.PostCol.CountDocuments(ctx, bson.M{})
+	if err != nil || count != 0 {
+		t.Errorf("CountDocuments = %d, %v; want 0, nil", count, err)
+	}
+	posts, err := db.FindPosts(ctx, bson.M{}, options.Find())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posts == nil || len(posts) != 0 {
+		t.Errorf("FindPosts on an empty collection = %#v, want an empty non-nil slice", posts)
+	}
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	countersCollection = "counters"
+	postIDCounter      = "post_id"
+)
+
+// CounterCol holds named sequences, one document per sequence
+var CounterCol *mongo.Collection
+
+// NextPostID atomically hands out the next post id. Ids come from a counter
+// that only ever goes up, so an id is never reused, even after the post with
+// the highest id is deleted.
+func NextPostID(ctx context.Context) (int, error) {
+	return NextSequence(ctx, postIDCounter)
+}
+
+// IsReservedCounter reports whether name is a counter this service manages
+// itself and others must not advance
+func IsReservedCounter(name string) bool {
+	return name == postIDCounter
+}
+
+// NextSequence atomically increments the named counter and returns its new
+// value, creating it at 1 on first use
+func NextSequence(ctx context.Context, name string) (int, error) {
+	if CounterCol == nil {
+		return 0, errors.New("MongoDB is not connected")
+	}
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	next := func() error {
+		return CounterCol.FindOneAndUpdate(ctx, bson.M{"_id": name}, bson.M{"$inc": bson.M{"seq": 1}}, opts).Decode(&counter)
+	}
+	err := next()
+	if mongo.IsDuplicateKeyError(err) {
+		// Two first-ever calls raced to create the counter; it exists now
+		err = next()
+	}
+	return counter.Seq, err
+}
+
+// SeedPostIDs raises the post id counter to the highest id already stored,
+// deleted posts included. It never lowers the counter, so running it at every
+// startup is safe.
+func SeedPostIDs(ctx context.Context) (int, error) {
+	var result struct {
+		MaxID int `bson:"maxID"`
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "id", Value: -1}}}},
+		{{Key: "$limit", Value: 1}},
+		{{Key: "$project", Value: bson.D{{Key: "maxID", Value: "$id"}}}},
+	}
+	cursor, err := PostCol.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, err
+		}
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err = CounterCol.UpdateOne(ctx, bson.M{"_id": postIDCounter}, bson.M{"$max": bson.M{"seq": result.MaxID}}, opts)
+	return result.MaxID, err
+}
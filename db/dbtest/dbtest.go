@@ -0,0 +1,45 @@
+// Package dbtest connects tests to a real MongoDB. Tests that need one call
+// Connect, which skips them unless MONGODB_TEST_URL is set, e.g.
+//
+//	MONGODB_TEST_URL=mongodb://localhost:27017 go test ./...
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"go-server/db"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Connect points the db package at a fresh database on MONGODB_TEST_URL and
+// drops it when the test ends
+func Connect(t testing.TB) {
+	t.Helper()
+	url := os.Getenv("MONGODB_TEST_URL")
+	if url == "" {
+		t.Skip("MONGODB_TEST_URL not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(url).SetMonitor(db.OpMonitor()))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	database := client.Database(fmt.Sprintf("gocore_test_%d", time.Now().UnixNano()))
+	if err := db.Use(ctx, client, database); err != nil {
+		client.Disconnect(context.Background())
+		t.Fatalf("preparing test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		database.Drop(context.Background())
+		client.Disconnect(context.Background())
+		db.Client, db.PostCol, db.CounterCol, db.SnapshotCol = nil, nil, nil, nil
+	})
+}
@@ -0,0 +1,8 @@
+package db
+
+// SetMaxListBytes lets external tests lower the list size valve
+func SetMaxListBytes(n int) (restore func()) {
+	old := maxListBytes
+	maxListBytes = n
+	return func() { maxListBytes = old }
+}
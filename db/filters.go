@@ -0,0 +1,10 @@
+package db
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Active restricts filter to posts that haven't been soft-deleted. Deleted
+// posts stay in the collection as tombstones for the change feed.
+func Active(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$exists": false}
+	return filter
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// postIndexes are every index the posts collection is expected to have.
+// Names are explicit so their presence can be checked after a restore.
+var postIndexes = []mongo.IndexModel{
+	{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetName("id_1").SetUnique(true),
+	},
+	{
+		Keys:    bson.D{{Key: "body", Value: "text"}},
+		Options: options.Index().SetName("body_text"),
+	},
+	{
+		// Posts created by findOrCreate claim their body here, so two
+		// concurrent calls can't both insert it
+		Keys: bson.D{{Key: "body_hash", Value: 1}},
+		Options: options.Index().
+			SetName("body_hash_1").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"body_hash": bson.M{"$exists": true}}),
+	},
+	{
+		Keys:    bson.D{{Key: "created_at", Value: -1}},
+		Options: options.Index().SetName("created_at_-1"),
+	},
+	{
+		// Only tombstones are indexed, keeping the index small
+		Keys: bson.D{{Key: "deleted_at", Value: 1}},
+		Options: options.Index().
+			SetName("deleted_at_1").
+			SetPartialFilterExpression(bson.M{"deleted_at": bson.M{"$exists": true}}),
+	},
+}
+
+// IndexStatus reports what EnsureIndexes did for one index
+type IndexStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "created" or "present"
+}
+
+// EnsureIndexes creates any expected index missing from col. It is safe to
+// run repeatedly; indexes that already exist are left alone.
+func EnsureIndexes(ctx context.Context, col *mongo.Collection) ([]IndexStatus, error) {
+	return ensureIndexes(ctx, col, postIndexes)
+}
+
+func ensureIndexes(ctx context.Context, col *mongo.Collection, models []mongo.IndexModel) ([]IndexStatus, error) {
+	existing, err := indexNames(ctx, col)
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]IndexStatus, 0, len(models))
+	for _, model := range models {
+		name := *model.Options.Name
+		if existing[name] {
+			report = append(report, IndexStatus{Name: name, Status: "present"})
+			continue
+		}
+		if _, err := col.Indexes().CreateOne(ctx, model); err != nil {
+			return report, err
+		}
+		report = append(report, IndexStatus{Name: name, Status: "created"})
+	}
+	return report, nil
+}
+
+func indexNames(ctx context.Context, col *mongo.Collection) (map[string]bool, error) {
+	cursor, err := col.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var specs []struct {
+		Name string `bson:"name"`
+	}
+	if err := cursor.All(ctx, &specs); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		names[spec.Name] = true
+	}
+	return names, nil
+}
@@ -3,47 +3,102 @@ package db
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"go-server/utils"
 	"log"
 	"os"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const (
+	databaseName    = "Go"
+	postsCollection = "posts"
+	maxPoolSize     = 100
+	minPoolSize     = 5
+	maxConnIdleTime = 30 * time.Second
+)
+
+// Settings is the effective MongoDB configuration, safe to expose to operators
+type Settings struct {
+	URL             string `json:"url"`
+	Database        string `json:"database"`
+	Collection      string `json:"collection"`
+	MaxPoolSize     int    `json:"maxPoolSize"`
+	MinPoolSize     int    `json:"minPoolSize"`
+	MaxConnIdleTime string `json:"maxConnIdleTime"`
+}
+
 var (
 	Client  *mongo.Client
 	PostCol *mongo.Collection
 	ctx     = context.Background()
 )
 
-func InitMongoDB() {
+// InitMongoDB connects to MongoDB and prepares the posts collection.
+// On failure PostCol stays nil and the caller decides whether to keep going.
+func InitMongoDB() error {
 	mongoURL := os.Getenv("MONGODB_URL")
 	if mongoURL == "" {
-		log.Fatal("MONGODB_URL is not set")
+		return errors.New("MONGODB_URL is not set")
 	}
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	log.Printf("Connecting to MongoDB at %s", utils.RedactURI(mongoURL))
 	clientOptions := buildMongoClientOptions(mongoURL)
-	var err error
 
-	Client, err = mongo.Connect(ctx, clientOptions)
+	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		log.Fatal("MongoDB Connection Error:", err)
+		return fmt.Errorf("MongoDB connection error: %s", utils.RedactURIIn(err.Error(), mongoURL))
 	}
-	if err = Client.Ping(ctx, nil); err != nil {
-		log.Fatal("MongoDB Ping Error: %v", err)
+	if err = client.Ping(ctx, nil); err != nil {
+		client.Disconnect(context.Background())
+		return fmt.Errorf("MongoDB ping error: %s", utils.RedactURIIn(err.Error(), mongoURL))
 	}
 
-	PostCol = Client.Database("Go").Collection("posts")
-	if err := ensurePostIndex(ctx, PostCol); err != nil {
-		log.Fatalf("Failed to create index: %v", err)
+	if err := Use(ctx, client, client.Database(databaseName)); err != nil {
+		client.Disconnect(context.Background())
+		return err
 	}
 	fmt.Println("Connected to MongoDB!")
+	return nil
+}
+
+// Use points the package at database, creating the posts collection and the
+// posts and snapshot indexes when they are missing. InitMongoDB calls it for the configured
+// database; tests call it for a throwaway one.
+func Use(ctx context.Context, client *mongo.Client, database *mongo.Database) error {
+	if err := ensureCollection(ctx, database, postsCollection); err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+	col := database.Collection(postsCollection)
+	if _, err := EnsureIndexes(ctx, col); err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+
+	snapshots := database.Collection(snapshotsCollection)
+	if _, err := ensureIndexes(ctx, snapshots, snapshotIndexes); err != nil {
+		return fmt.Errorf("failed to create snapshot index: %w", err)
+	}
+
+	Client = client
+	PostCol = col
+	CounterCol = database.Collection(countersCollection)
+	SnapshotCol = snapshots
+	return nil
+}
+
+// Ping checks that MongoDB is reachable
+func Ping(ctx context.Context) error {
+	if Client == nil {
+		return errors.New("MongoDB is not connected")
+	}
+	return Client.Ping(ctx, nil)
 }
 
 func buildMongoClientOptions(uri string) *options.ClientOptions {
@@ -54,17 +109,30 @@ func buildMongoClientOptions(uri string) *options.ClientOptions {
 
 	return options.Client().
 		ApplyURI(uri).
-		SetMaxPoolSize(100).
-		SetMinPoolSize(5).
-		SetMaxConnIdleTime(30 * time.Second).
-		SetTLSConfig(tlsConfig)
+		SetMaxPoolSize(maxPoolSize).
+		SetMinPoolSize(minPoolSize).
+		SetMaxConnIdleTime(maxConnIdleTime).
+		SetTLSConfig(tlsConfig).
+		SetMonitor(opMonitor)
+}
+
+// CurrentSettings reports the Mongo configuration with the connection string
+// as configured; callers must redact it before exposing it
+func CurrentSettings() Settings {
+	return Settings{
+		URL:             os.Getenv("MONGODB_URL"),
+		Database:        databaseName,
+		Collection:      postsCollection,
+		MaxPoolSize:     maxPoolSize,
+		MinPoolSize:     minPoolSize,
+		MaxConnIdleTime: maxConnIdleTime.String(),
+	}
 }
 
-func ensurePostIndex(ctx context.Context, col *mongo.Collection) error {
-	indexModel := mongo.IndexModel{
-		Keys:    bson.M{"id": 1},
-		Options: options.Index().SetUnique(true),
+// Close disconnects from MongoDB
+func Close(ctx context.Context) error {
+	if Client == nil {
+		return nil
 	}
-	_, err := col.Indexes().CreateOne(ctx, indexModel)
-	return err
+	return Client.Disconnect(ctx)
 }
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+type opCounterKey struct{}
+
+// WithOpCounter returns a context that counts every MongoDB command issued
+// with it or any context derived from it
+func WithOpCounter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, opCounterKey{}, new(atomic.Int64))
+}
+
+// OpCount reports how many MongoDB commands have run under ctx so far, or 0
+// when ctx carries no counter
+func OpCount(ctx context.Context) int64 {
+	if n, ok := ctx.Value(opCounterKey{}).(*atomic.Int64); ok {
+		return n.Load()
+	}
+	return 0
+}
+
+// opMonitor counts commands against the counter in the operation's context.
+// Retries and cursor getMores each count, since each is a round trip.
+var opMonitor = &event.CommandMonitor{
+	Started: func(ctx context.Context, _ *event.CommandStartedEvent) {
+		if n, ok := ctx.Value(opCounterKey{}).(*atomic.Int64); ok {
+			n.Add(1)
+		}
+	},
+}
+
+// OpMonitor returns the monitor behind OpCount, for clients created outside
+// InitMongoDB such as the one dbtest connects
+func OpMonitor() *event.CommandMonitor {
+	return opMonitor
+}
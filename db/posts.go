@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"go-server/models"
+	"log"
+	"os"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultMaxListBytes = 16 << 20
+
+// ErrResultTooLarge is returned when a list query would decode more data than
+// MAX_LIST_BYTES allows
+var ErrResultTooLarge = errors.New("result exceeds the list size limit")
+
+var maxListBytes = defaultMaxListBytes
+
+// InitListLimits reads MAX_LIST_BYTES, the most raw BSON a single list query
+// may load into memory, independent of the requested page size
+func InitListLimits() {
+	raw := os.Getenv("MAX_LIST_BYTES")
+	if raw == "" {
+		return
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		log.Fatalf("Invalid MAX_LIST_BYTES %q: must be a positive number of bytes", raw)
+	}
+	maxListBytes = n
+}
+
+// FindPosts decodes matching posts one document at a time and gives up with
+// ErrResultTooLarge once the documents read exceed MAX_LIST_BYTES, instead of
+// loading an unbounded result with cursor.All
+func FindPosts(ctx context.Context, filter interface{}, opts *options.FindOptions) ([]models.Post, error) {
+	// Never nil, so an empty result encodes as [] rather than null
+	posts := []models.Post{}
+	err := WithReadRetry(ctx, func() error {
+		posts = posts[:0]
+		cursor, err := PostCol.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+
+		size := 0
+		for cursor.Next(ctx) {
+			size += len(cursor.Current)
+			if size > maxListBytes {
+				log.Printf("List query aborted after %d bytes (limit %d)", size, maxListBytes)
+				return ErrResultTooLarge
+			}
+
+			var p models.Post
+			if err := cursor.Decode(&p); err != nil {
+				return err
+			}
+			posts = append(posts, p)
+		}
+		return cursor.Err()
+	})
+	return posts, err
+}
@@ -0,0 +1,52 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func insertLargePosts(t *testing.T, n, bodySize int) {
+	t.Helper()
+	now := models.Now()
+	docs := make([]interface{}, n)
+	for i := range docs {
+		docs[i] = models.Post{ID: i + 1, Body: strings.Repeat("x", bodySize), Version: 1, CreatedAt: now, UpdatedAt: now}
+	}
+	if _, err := db.PostCol.InsertMany(context.Background(), docs); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindPostsAbortsOversizedResult(t *testing.T) {
+	dbtest.Connect(t)
+	// 50 posts of 10KB against a 100KB valve; the limit alone would allow them all
+	insertLargePosts(t, 50, 10<<10)
+	defer db.SetMaxListBytes(100 << 10)()
+
+	posts, err := db.FindPosts(context.Background(), bson.M{}, options.Find().SetLimit(1000))
+	if !errors.Is(err, db.ErrResultTooLarge) {
+		t.Fatalf("got %d posts and error %v, want ErrResultTooLarge", len(posts), err)
+	}
+}
+
+func TestFindPostsUnderLimit(t *testing.T) {
+	dbtest.Connect(t)
+	insertLargePosts(t, 5, 10<<10)
+	defer db.SetMaxListBytes(100 << 10)()
+
+	posts, err := db.FindPosts(context.Background(), bson.M{}, options.Find())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 5 {
+		t.Errorf("got %d posts, want 5", len(posts))
+	}
+}
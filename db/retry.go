@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultReadRetries = 1
+	readRetryBackoff   = 50 * time.Millisecond
+)
+
+var readRetries = defaultReadRetries
+
+// InitReadRetries reads READ_RETRIES, the number of extra attempts a read
+// gets after a transient failure. Writes are never retried.
+func InitReadRetries() {
+	raw := os.Getenv("READ_RETRIES")
+	if raw == "" {
+		return
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		log.Fatalf("Invalid READ_RETRIES %q: must be a non-negative number", raw)
+	}
+	readRetries = n
+}
+
+// WithReadRetry runs a read operation and retries it with a short linear
+// backoff when it fails with a transient network error
+func WithReadRetry(ctx context.Context, read func() error) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = read()
+		if err == nil || attempt >= readRetries || !isTransient(err) {
+			return err
+		}
+		log.Printf("Transient read error, retrying (attempt %d): %v", attempt+1, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(readRetryBackoff * time.Duration(attempt+1)):
+		}
+	}
+}
+
+func isTransient(err error) bool {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false
+	}
+	return mongo.IsNetworkError(err) || mongo.IsTimeout(err)
+}
+
+// FindOne decodes the first document matching filter, retrying transient failures
+func FindOne(ctx context.Context, filter interface{}, target interface{}) error {
+	return WithReadRetry(ctx, func() error {
+		return PostCol.FindOne(ctx, filter).Decode(target)
+	})
+}
+
+// Find decodes every document matching filter, retrying transient failures
+func Find(ctx context.Context, filter interface{}, opts *options.FindOptions, target interface{}) error {
+	return WithReadRetry(ctx, func() error {
+		cursor, err := PostCol.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, target)
+	})
+}
+
+// Count counts the documents matching filter, retrying transient failures
+func Count(ctx context.Context, filter interface{}) (int64, error) {
+	var count int64
+	err := WithReadRetry(ctx, func() error {
+		var err error
+		count, err = PostCol.CountDocuments(ctx, filter)
+		return err
+	})
+	return count, err
+}
+
+// Aggregate decodes every document the pipeline produces, retrying transient failures
+func Aggregate(ctx context.Context, pipeline interface{}, target interface{}) error {
+	return WithReadRetry(ctx, func() error {
+		cursor, err := PostCol.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		return cursor.All(ctx, target)
+	})
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var errTransient = mongo.CommandError{Message: "connection reset", Labels: []string{"NetworkError"}}
+
+func TestReadSucceedsOnSecondAttempt(t *testing.T) {
+	calls := 0
+	err := WithReadRetry(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return errTransient
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("got %v after %d calls, want success on the second", err, calls)
+	}
+}
+
+func TestReadRetryLimits(t *testing.T) {
+	defer func(n int) { readRetries = n }(readRetries)
+
+	tests := []struct {
+		name    string
+		retries int
+		err     error
+		calls   int
+	}{
+		{"gives up after READ_RETRIES", 2, errTransient, 3},
+		{"retries disabled", 0, errTransient, 1},
+		{"not found is final", 2, mongo.ErrNoDocuments, 1},
+		{"other errors are final", 2, errors.New("bad query"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readRetries = tt.retries
+			calls := 0
+			err := WithReadRetry(context.Background(), func() error {
+				calls++
+				return tt.err
+			})
+			if err == nil || err.Error() != tt.err.Error() || calls != tt.calls {
+				t.Errorf("got %v after %d calls, want %v after %d", err, calls, tt.err, tt.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	snapshotsCollection = "post_count_snapshots"
+	// SnapshotRetention is how long a snapshot is kept before MongoDB's TTL
+	// monitor removes it
+	SnapshotRetention = 90 * 24 * time.Hour
+)
+
+// SnapshotCol holds periodic post counts, one small document per snapshot
+var SnapshotCol *mongo.Collection
+
+// snapshotIndexes keep one snapshot per slot however many instances record
+// it, and expire snapshots after SnapshotRetention
+var snapshotIndexes = []mongo.IndexModel{
+	{
+		Keys: bson.D{{Key: "at", Value: 1}},
+		Options: options.Index().
+			SetName("at_1").
+			SetUnique(true).
+			SetExpireAfterSeconds(int32(SnapshotRetention / time.Second)),
+	},
+}
+
+// CountSnapshot is the number of live posts at a point in time
+type CountSnapshot struct {
+	At    time.Time `json:"at" bson:"at"`
+	Count int64     `json:"count" bson:"count"`
+}
+
+// RecordCountSnapshot counts the live posts and stores the result as of at,
+// unless a snapshot for at is already stored. Callers round at down to their
+// interval, so every instance and every restart within a slot share one
+// snapshot. The stored snapshot is returned.
+func RecordCountSnapshot(ctx context.Context, at time.Time) (CountSnapshot, error) {
+	count, err := Count(ctx, Active(bson.M{}))
+	if err != nil {
+		return CountSnapshot{}, err
+	}
+	filter := bson.M{"at": at.UTC()}
+	update := bson.M{"$setOnInsert": bson.M{"count": count}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var snap CountSnapshot
+	err = SnapshotCol.FindOneAndUpdate(ctx, filter, update, opts).Decode(&snap)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another instance inserted the slot first; this time the filter matches
+		err = SnapshotCol.FindOneAndUpdate(ctx, filter, update, opts).Decode(&snap)
+	}
+	if err != nil {
+		return CountSnapshot{}, err
+	}
+	return snap, nil
+}
+
+// RecentCountSnapshots returns up to limit of the latest snapshots, oldest
+// first
+func RecentCountSnapshots(ctx context.Context, limit int64) ([]CountSnapshot, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}}).SetLimit(limit)
+	snaps := []CountSnapshot{}
+	err := WithReadRetry(ctx, func() error {
+		cursor, err := SnapshotCol.Find(ctx, bson.M{}, opts)
+		if err != nil {
+			return err
+		}
+		defer cursor.Close(ctx)
+		snaps = snaps[:0]
+		return cursor.All(ctx, &snaps)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(snaps)
+	return snaps, nil
+}
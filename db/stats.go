@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CollectionStats is the part of collStats useful for capacity planning.
+// Sizes are in bytes.
+type CollectionStats struct {
+	Count          int64            `json:"count" bson:"count"`
+	StorageSize    int64            `json:"storageSize" bson:"storageSize"`
+	AvgObjSize     float64          `json:"avgObjSize" bson:"avgObjSize"`
+	TotalIndexSize int64            `json:"totalIndexSize" bson:"totalIndexSize"`
+	IndexSizes     map[string]int64 `json:"indexSizes" bson:"indexSizes"`
+	// Restricted is set when the deployment refused collStats and only the
+	// document count could be gathered
+	Restricted bool `json:"restricted"`
+}
+
+// Server error codes meaning the command isn't allowed here
+const (
+	codeUnauthorized       = 13
+	codeCommandNotFound    = 59
+	codeAPIStrictForbidden = 323
+)
+
+// PostStats runs collStats on the posts collection. Managed deployments may
+// forbid the command; then only the document count is returned.
+func PostStats(ctx context.Context) (CollectionStats, error) {
+	var stats CollectionStats
+	err := PostCol.Database().RunCommand(ctx, bson.D{{Key: "collStats", Value: postsCollection}}).Decode(&stats)
+	if err == nil {
+		return stats, nil
+	}
+
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || !isForbidden(cmdErr.Code) {
+		return CollectionStats{}, err
+	}
+	count, err := Count(ctx, bson.M{})
+	if err != nil {
+		return CollectionStats{}, err
+	}
+	return CollectionStats{Count: count, IndexSizes: map[string]int64{}, Restricted: true}, nil
+}
+
+func isForbidden(code int32) bool {
+	switch code {
+	case codeUnauthorized, codeCommandNotFound, codeAPIStrictForbidden:
+		return true
+	}
+	return false
+}
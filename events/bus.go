@@ -0,0 +1,71 @@
+package events
+
+import (
+	"go-server/models"
+	"log"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	PostCreated Type = "post.created"
+	PostUpdated Type = "post.updated"
+	PostDeleted Type = "post.deleted"
+	// PostsDeleted is one bulk delete; PostIDs lists every post it removed
+	PostsDeleted Type = "posts.deleted"
+)
+
+// Event describes a change to a post. Post holds the stored state after the
+// change when the publisher has it; PostID is set for every type but
+// PostsDeleted, which carries PostIDs instead.
+type Event struct {
+	Type    Type
+	PostID  int
+	PostIDs []int
+	Post    models.Post
+	At      time.Time
+}
+
+type Handler func(Event)
+
+var (
+	mu          sync.RWMutex
+	subscribers = make(map[Type][]Handler)
+)
+
+// Subscribe registers h to run for every event of the given types
+func Subscribe(h Handler, types ...Type) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, t := range types {
+		subscribers[t] = append(subscribers[t], h)
+	}
+}
+
+// Publish delivers e to its subscribers synchronously, in registration order,
+// so side effects like cache invalidation finish before the response is sent.
+// A panicking subscriber is logged and doesn't stop the others.
+func Publish(e Event) {
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+
+	mu.RLock()
+	handlers := append([]Handler(nil), subscribers[e.Type]...)
+	mu.RUnlock()
+
+	for _, h := range handlers {
+		deliver(h, e)
+	}
+}
+
+func deliver(h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event subscriber for %s panicked: %v", e.Type, r)
+		}
+	}()
+	h(e)
+}
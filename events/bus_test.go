@@ -0,0 +1,61 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+// reset drops every subscriber once the test ends
+func reset(t *testing.T) {
+	t.Cleanup(func() {
+		mu.Lock()
+		subscribers = make(map[Type][]Handler)
+		mu.Unlock()
+	})
+}
+
+func TestPublishReachesSubscribers(t *testing.T) {
+	reset(t)
+	var order []string
+	var got Event
+	Subscribe(func(e Event) { order = append(order, "cache"); got = e }, PostCreated, PostUpdated)
+	Subscribe(func(e Event) { order = append(order, "webhooks") }, PostCreated)
+	Subscribe(func(e Event) { order = append(order, "audit") }, PostDeleted)
+
+	Publish(Event{Type: PostCreated, PostID: 3})
+	if len(order) != 2 || order[0] != "cache" || order[1] != "webhooks" {
+		t.Errorf("created reached %v, want [cache webhooks] in that order", order)
+	}
+	if got.PostID != 3 || got.At.IsZero() || time.Since(got.At) > time.Minute {
+		t.Errorf("delivered %+v, want post 3 stamped with the publish time", got)
+	}
+
+	order = nil
+	Publish(Event{Type: PostDeleted, PostID: 3})
+	if len(order) != 1 || order[0] != "audit" {
+		t.Errorf("deleted reached %v, want [audit]", order)
+	}
+}
+
+func TestPanickingSubscriberDoesNotStopOthers(t *testing.T) {
+	reset(t)
+	reached := false
+	Subscribe(func(Event) { panic("boom") }, PostUpdated)
+	Subscribe(func(Event) { reached = true }, PostUpdated)
+
+	Publish(Event{Type: PostUpdated, PostID: 1})
+	if !reached {
+		t.Error("subscriber after a panicking one never ran")
+	}
+}
+
+func TestPublishKeepsGivenTime(t *testing.T) {
+	reset(t)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var got time.Time
+	Subscribe(func(e Event) { got = e.At }, PostUpdated)
+	Publish(Event{Type: PostUpdated, At: at})
+	if !got.Equal(at) {
+		t.Errorf("At = %s, want %s", got, at)
+	}
+}
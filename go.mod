@@ -3,17 +3,18 @@ module go-server
 go 1.22.0
 
 require (
+	github.com/alicebob/miniredis/v2 v2.33.0
+	github.com/go-redis/redis v6.15.9+incompatible
 	github.com/joho/godotenv v1.5.1
-	github.com/redis/go-redis/v9 v9.7.3
+	github.com/rs/cors v1.11.1
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	go.mongodb.org/mongo-driver v1.17.3
+	golang.org/x/sync v0.8.0
+	golang.org/x/text v0.17.0
 )
 
-require github.com/rs/cors v1.11.1 // indirect
-
 require (
-	github.com/cespare/xxhash/v2 v2.2.0 // indirect
-	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
-	github.com/go-redis/redis v6.15.9+incompatible
+	github.com/alicebob/gopher-json v0.0.0-20200520072559-a9ecdc9d1d3a // indirect
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/klauspost/compress v1.16.7 // indirect
 	github.com/montanaflynn/stats v0.7.1 // indirect
@@ -21,7 +22,6 @@ require (
 	github.com/xdg-go/scram v1.1.2 // indirect
 	github.com/xdg-go/stringprep v1.0.4 // indirect
 	github.com/youmark/pkcs8 v0.0.0-20240726163527-a2c0da244d78 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
 	golang.org/x/crypto v0.26.0 // indirect
-	golang.org/x/sync v0.8.0 // indirect
-	golang.org/x/text v0.17.0 // indirect
 )
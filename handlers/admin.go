@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-server/cache"
+	"go-server/db"
+	"go-server/middleware"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ServerConfig struct {
+	DBTimeout        string `json:"dbTimeout"`
+	DefaultPageLimit int    `json:"defaultPageLimit"`
+	MaxPageLimit     int    `json:"maxPageLimit"`
+	GzipLevel        int    `json:"gzipLevel"`
+}
+
+// FeatureFlags are the behaviour switches that change how requests are
+// answered, as opposed to sizes and timeouts
+type FeatureFlags struct {
+	ReadMode          string `json:"readMode"`
+	RootMode          string `json:"rootMode"`
+	StrictDelete      bool   `json:"strictDelete"`
+	StrictAccept      bool   `json:"strictAccept"`
+	StrictContentType bool   `json:"strictContentType"`
+	ServeStaleOnError bool   `json:"serveStaleOnError"`
+}
+
+type RuntimeConfig struct {
+	Version  string         `json:"version"`
+	Server   ServerConfig   `json:"server"`
+	Mongo    db.Settings    `json:"mongo"`
+	Redis    cache.Settings `json:"redis"`
+	Features FeatureFlags   `json:"features"`
+}
+
+// CurrentConfig reports the configuration that actually took effect, with
+// secrets redacted so it can be shown or logged as-is
+func CurrentConfig() RuntimeConfig {
+	mongoSettings := db.CurrentSettings()
+	mongoSettings.URL = utils.RedactURI(mongoSettings.URL)
+
+	redisSettings := cache.CurrentSettings()
+	redisSettings.Addr = utils.RedactURI(redisSettings.Addr)
+	if redisSettings.Password != "" {
+		redisSettings.Password = utils.Redacted
+	}
+
+	return RuntimeConfig{
+		Version: Version,
+		Server: ServerConfig{
+			DBTimeout:        dbTimeout.String(),
+			DefaultPageLimit: utils.DefaultPageLimit,
+			MaxPageLimit:     utils.MaxPageLimit(),
+			GzipLevel:        middleware.GzipLevel(),
+		},
+		Mongo: mongoSettings,
+		Redis: redisSettings,
+		Features: FeatureFlags{
+			ReadMode:          readMode,
+			RootMode:          rootMode,
+			StrictDelete:      strictDelete,
+			StrictAccept:      strictAccept,
+			StrictContentType: requireContentType,
+			ServeStaleOnError: serveStaleOnError,
+		},
+	}
+}
+
+// Handling function for /admin/config endpoint
+// Reports the configuration that actually took effect, with secrets redacted
+func AdminConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	utils.RespondWithJSON(w, CurrentConfig())
+}
+
+type ReindexResponse struct {
+	Indexes []db.IndexStatus `json:"indexes"`
+}
+
+// Handling function for /admin/reindex endpoint
+// Recreates any missing index on the posts collection, e.g. after restoring a dump
+func AdminReindexHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 30*time.Second)
+	defer cancel()
+
+	report, err := db.EnsureIndexes(ctx, db.PostCol)
+	if err != nil {
+		log.Printf("Error ensuring indexes: %v", err)
+		utils.Error(w, "Error ensuring indexes", http.StatusInternalServerError)
+		return
+	}
+	utils.RespondWithJSON(w, ReindexResponse{Indexes: report})
+}
+
+type CacheEntryResponse struct {
+	cache.Entry
+	TTLSeconds int64 `json:"ttlSeconds"`
+}
+
+// Handling function for /admin/cache/{key} endpoint
+// Shows what the cache holds for a key so it can be compared with MongoDB
+func AdminCacheEntryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !cache.Enabled() {
+		utils.RespondWithError(w, http.StatusServiceUnavailable, utils.CodeCacheUnavailable, "Cache unavailable")
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/admin/cache/")
+	entry, found, err := cache.Inspect(key)
+	switch {
+	case errors.Is(err, cache.ErrForeignKey):
+		utils.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		log.Printf("Error inspecting cache key %q: %v", key, err)
+		utils.Error(w, "Error reading cache", http.StatusInternalServerError)
+		return
+	case !found:
+		utils.Error(w, "Cache entry not found", http.StatusNotFound)
+		return
+	}
+	utils.RespondWithJSON(w, CacheEntryResponse{Entry: entry, TTLSeconds: int64(entry.TTL.Seconds())})
+}
+
+type PurgeRequest struct {
+	Pattern string `json:"pattern"`
+}
+
+type PurgeResponse struct {
+	Pattern string `json:"pattern"`
+	Removed int64  `json:"removed"`
+}
+
+// Handling function for /admin/cache/purge endpoint
+// Deletes the cache keys matching a pattern within the service's namespaces,
+// e.g. {"pattern":"posts:list:*"} to drop every list page after a bulk change
+func AdminCachePurgeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, ok := readJSONBody(w, r)
+	if !ok {
+		return
+	}
+	var req PurgeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		utils.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Pattern == "" {
+		utils.Error(w, "pattern is required", http.StatusBadRequest)
+		return
+	}
+	if !cache.Enabled() {
+		utils.RespondWithError(w, http.StatusServiceUnavailable, utils.CodeCacheUnavailable, "Cache unavailable")
+		return
+	}
+
+	removed, err := cache.Purge(req.Pattern)
+	switch {
+	case errors.Is(err, cache.ErrForeignKey):
+		utils.Error(w, "pattern must start with post:, posts:list: or stale:post:", http.StatusBadRequest)
+		return
+	case err != nil:
+		log.Printf("Error purging cache keys %q after removing %d: %v", req.Pattern, removed, err)
+		utils.Error(w, "Error purging cache", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Purged %d cache keys matching %q", removed, req.Pattern)
+	utils.RespondWithJSON(w, PurgeResponse{Pattern: req.Pattern, Removed: removed})
+}
+
+// Handling function for /admin/db/stats endpoint
+// Size and count of the posts collection and its indexes, for capacity planning
+func AdminDBStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	stats, err := db.PostStats(ctx)
+	if err != nil {
+		log.Printf("Error reading collection stats: %v", err)
+		utils.Error(w, "Error reading collection stats", http.StatusInternalServerError)
+		return
+	}
+	utils.RespondWithJSON(w, stats)
+}
+
+type PrimeResponse struct {
+	Key   string `json:"key"`
+	Found bool   `json:"found"`
+	Posts int    `json:"posts"`
+}
+
+// Handling function for /admin/cache/prime/{id} endpoint
+// Loads a post from MongoDB into the cache, e.g. to rewarm hot posts after
+// mass invalidation. /admin/cache/prime/list primes a GET /posts page instead,
+// taking the same query parameters (the first page by default).
+func AdminCachePrimeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !cache.Enabled() {
+		utils.RespondWithError(w, http.StatusServiceUnavailable, utils.CodeCacheUnavailable, "Cache unavailable")
+		return
+	}
+	target := strings.TrimPrefix(r.URL.Path, "/admin/cache/prime/")
+	route := cache.RoutePost
+	if target == "list" {
+		route = cache.RouteList
+	}
+	// Nothing would be stored, so don't report the post as primed
+	if !cache.EnabledFor(route) {
+		utils.RespondWithError(w, http.StatusConflict, utils.CodeCacheDisabled, fmt.Sprintf("Caching is disabled for the %s route", route))
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	if target == "list" {
+		primeList(w, r)
+		return
+	}
+	id, err := strconv.Atoi(target)
+	if err != nil {
+		utils.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	// loadPost always reads MongoDB and, with the route enabled, writes the
+	// result to the cache
+	_, err = loadPost(r.Context(), id)
+	switch {
+	case errors.Is(err, errPostNotFound):
+		utils.RespondWithJSON(w, PrimeResponse{Key: cache.BuildPostKey(id)})
+	case err != nil:
+		respondFetchError(w, err)
+	default:
+		utils.RespondWithJSON(w, PrimeResponse{Key: cache.BuildPostKey(id), Found: true, Posts: 1})
+	}
+}
+
+func primeList(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r)
+	if err != nil {
+		utils.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	if err := q.resolveAfter(ctx); err != nil {
+		if errors.Is(err, errUnknownAnchor) {
+			utils.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error resolving after cursor %d: %v", q.After, err)
+		utils.Error(w, "Error priming cache", http.StatusInternalServerError)
+		return
+	}
+	ps, err := db.FindPosts(ctx, db.Active(q.pageFilter()), q.findOptions())
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+
+	count, err := db.Count(ctx, db.Active(q.filter()))
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+	page, err := renderListPage(q, ps, count)
+	if err != nil {
+		log.Printf("Error encoding posts page: %v", err)
+		utils.Error(w, "Error priming cache", http.StatusInternalServerError)
+		return
+	}
+
+	key := cache.BuildPostsListKey(q.cacheKey())
+	cache.CachePostsList(key, page)
+	utils.RespondWithJSON(w, PrimeResponse{Key: key, Found: len(ps) > 0, Posts: len(ps)})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-server/cache"
+	"go-server/db"
+	"go-server/metrics"
+	"go-server/models"
+	"go-server/utils"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const maxBatchSize = 100
+
+type BatchRequest struct {
+	IDs []int `json:"ids"`
+}
+
+type BatchItem struct {
+	ID        int          `json:"id"`
+	Source    string       `json:"source,omitempty"`
+	LatencyMs float64      `json:"latencyMs"`
+	Post      *models.Post `json:"post,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Items     []BatchItem `json:"items"`
+	CacheHits int         `json:"cacheHits"`
+	DBReads   int         `json:"dbReads"`
+	TotalMs   float64     `json:"totalMs"`
+}
+
+// Handling function for /posts/batch endpoint
+// Fetches several posts and reports, per post, whether it came from the
+// cache or the database and how long it took
+func BatchGetPostsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, ok := readJSONBody(w, r)
+	if !ok {
+		return
+	}
+	var req BatchRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		utils.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 {
+		utils.Error(w, "ids is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) > maxBatchSize {
+		utils.Error(w, fmt.Sprintf("Too many ids: %d (max %d)", len(req.IDs), maxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	resp := BatchResponse{Items: make([]BatchItem, len(req.IDs))}
+	var misses []int
+	for i, id := range req.IDs {
+		resp.Items[i] = batchFromCache(id)
+		if resp.Items[i].Source == "cache" {
+			resp.CacheHits++
+		} else {
+			misses = append(misses, i)
+		}
+	}
+	if len(misses) > 0 {
+		resp.DBReads = batchFromDB(r.Context(), resp.Items, misses)
+	}
+	resp.TotalMs = elapsedMs(start)
+	utils.RespondWithJSON(w, resp)
+}
+
+// batchFromCache follows the same cache-first path as a single GET; items
+// without a Source still need a database read
+func batchFromCache(id int) BatchItem {
+	start := time.Now()
+	item := BatchItem{ID: id}
+
+	if cache.EnabledFor(cache.RoutePost) {
+		if cached, _, found := cache.GetCachedPost(id); found {
+			metrics.CacheHit(metrics.EndpointPost)
+			item.Source, item.Post = "cache", &cached
+			item.LatencyMs = elapsedMs(start)
+			return item
+		}
+		metrics.CacheMiss(metrics.EndpointPost)
+	}
+	return item
+}
+
+// batchFromDB fills the items at the misses indexes with a single MongoDB
+// query rather than one per id, caches what it finds and returns how many
+// posts came from the database. Each item reports the shared query's latency.
+func batchFromDB(parent context.Context, items []BatchItem, misses []int) int {
+	setError := func(msg string) {
+		for _, i := range misses {
+			items[i].Error = msg
+		}
+	}
+	if db.PostCol == nil {
+		setError("database unavailable")
+		return 0
+	}
+
+	ids := make([]int, len(misses))
+	for n, i := range misses {
+		ids[n] = items[i].ID
+	}
+
+	start := time.Now()
+	ctx, cancel := dbContext(parent)
+	defer cancel()
+	var posts []models.Post
+	err := db.Find(ctx, db.Active(bson.M{"id": bson.M{"$in": ids}}), nil, &posts)
+	latency := elapsedMs(start)
+	if err != nil {
+		setError("error fetching post")
+		return 0
+	}
+
+	byID := make(map[int]models.Post, len(posts))
+	for _, p := range posts {
+		byID[p.ID] = p
+		if cache.EnabledFor(cache.RoutePost) {
+			cache.CachePost(p)
+		}
+		if serveStaleOnError {
+			cache.CacheStalePost(p)
+		}
+	}
+
+	reads := 0
+	for _, i := range misses {
+		items[i].LatencyMs = latency
+		p, found := byID[items[i].ID]
+		if !found {
+			items[i].Error = "not found"
+			continue
+		}
+		items[i].Source, items[i].Post = "database", &p
+		reads++
+	}
+	return reads
+}
+
+func elapsedMs(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/cache"
+	"go-server/cache/cachetest"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func batchGet(t *testing.T, body string) (int, BatchResponse) {
+	t.Helper()
+	rec := serve(BatchGetPostsHandler, http.MethodPost, "/posts/batch", body)
+	var resp BatchResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestBatchReportsSourcePerItem(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+	cached := insertPost(t, models.Post{ID: 1, Body: "one", Version: 1})
+	insertPost(t, models.Post{ID: 2, Body: "two", Version: 1})
+	cache.CachePost(cached)
+
+	status, resp := batchGet(t, `{"ids":[1,2,3]}`)
+	if status != http.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	want := []struct {
+		source, body, err string
+	}{
+		{"cache", "one", ""},
+		{"database", "two", ""},
+		{"", "", "not found"},
+	}
+	for i, item := range resp.Items {
+		body := ""
+		if item.Post != nil {
+			body = item.Post.Body
+		}
+		if item.ID != i+1 || item.Source != want[i].source || body != want[i].body || item.Error != want[i].err {
+			t.Errorf("item %d = %+v (body %q), want %+v", i, item, body, want[i])
+		}
+		if item.LatencyMs < 0 {
+			t.Errorf("item %d latency %v", i, item.LatencyMs)
+		}
+	}
+	if resp.CacheHits != 1 || resp.DBReads != 1 {
+		t.Errorf("cacheHits %d, dbReads %d; want 1 and 1", resp.CacheHits, resp.DBReads)
+	}
+
+	// The database read cached post 2 for the next batch
+	_, resp = batchGet(t, `{"ids":[2]}`)
+	if len(resp.Items) != 1 || resp.Items[0].Source != "cache" {
+		t.Errorf("second batch %+v, want post 2 from the cache", resp.Items)
+	}
+}
+
+func TestBatchValidatesIDs(t *testing.T) {
+	tooMany := make([]string, maxBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = strconv.Itoa(i + 1)
+	}
+	for _, body := range []string{`{"ids":[]}`, `{}`, `{"ids":[` + strings.Join(tooMany, ",") + `]}`} {
+		if status, _ := batchGet(t, body); status != http.StatusBadRequest {
+			t.Errorf("%.40s: status %d, want 400", body, status)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-server/utils"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// requireContentType rejects JSON write requests that arrive without a
+// Content-Type header. Off by default so simple clients (curl without -H)
+// keep working; a wrong media type or charset is rejected either way.
+var requireContentType = false
+
+const defaultMaxBodyBytes = 1 << 20
+
+// maxBodyBytes is the largest request body any write endpoint accepts
+var maxBodyBytes atomic.Int64
+
+func init() {
+	maxBodyBytes.Store(defaultMaxBodyBytes)
+}
+
+// InitContentPolicy reads STRICT_CONTENT_TYPE and MAX_BODY_BYTES
+func InitContentPolicy() {
+	requireContentType, _ = strconv.ParseBool(os.Getenv("STRICT_CONTENT_TYPE"))
+
+	if err := LoadBodyLimit(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// LoadBodyLimit applies MAX_BODY_BYTES, keeping the current limit on a bad
+// value
+func LoadBodyLimit() error {
+	var n int64 = defaultMaxBodyBytes
+	if raw := os.Getenv("MAX_BODY_BYTES"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("Invalid MAX_BODY_BYTES %q: must be a positive number of bytes", raw)
+		}
+		n = parsed
+	}
+	maxBodyBytes.Store(n)
+	return nil
+}
+
+// readJSONBody reads a JSON request body after checking its declared media
+// type and charset, and makes sure the bytes are valid UTF-8 JSON. Every
+// write path (POST, PUT, PATCH) goes through here, so they share one
+// MAX_BODY_BYTES limit. When it returns false the error response has
+// already been written.
+func readJSONBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if !checkJSONContentType(w, r) {
+		return nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes.Load()))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.Error(w, fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+		log.Printf("Error reading request body: %v", err)
+		utils.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if !utf8.Valid(body) {
+		utils.Error(w, "Request body is not valid UTF-8", http.StatusBadRequest)
+		return nil, false
+	}
+	if msg, ok := checkSingleJSONObject(body); !ok {
+		utils.Error(w, msg, http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// checkSingleJSONObject accepts exactly one JSON object followed by nothing
+// but whitespace, so {"body":"x"}garbage or two concatenated objects get a
+// clear error rather than a generic one
+func checkSingleJSONObject(body []byte) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	var value json.RawMessage
+	if err := dec.Decode(&value); err != nil {
+		return "Invalid request body", false
+	}
+	if value[0] != '{' {
+		return "body must contain a single JSON object", false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return "body must contain a single JSON object", false
+	}
+	return "", true
+}
+
+func checkJSONContentType(w http.ResponseWriter, r *http.Request) bool {
+	header := r.Header.Get("Content-Type")
+	if header == "" {
+		if requireContentType {
+			utils.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return false
+		}
+		return true
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header)
+	if err != nil || mediaType != "application/json" {
+		utils.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return false
+	}
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+		utils.Error(w, "Unsupported charset "+strconv.Quote(charset)+", only utf-8 is accepted", http.StatusUnsupportedMediaType)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"go-server/db"
+	"go-server/events"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BodyReplaceRequest struct {
+	Find    string `json:"find"`
+	Replace string `json:"replace"`
+}
+
+// afterBodyRead runs between reading the post and the conditional write; tests
+// use it to land a concurrent edit in that window
+var afterBodyRead = func() {}
+
+// handlePatchPostBody replaces every occurrence of find in the post body.
+// The write is conditional on the version that was read, so a concurrent
+// edit makes this request fail with 409 instead of being overwritten. Like the
+// other writes it honors If-Unmodified-Since, failing with 412.
+func handlePatchPostBody(w http.ResponseWriter, r *http.Request, id int) {
+	if !requireDB(w) {
+		return
+	}
+
+	body, ok := readJSONBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req BodyReplaceRequest
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Find == "" {
+		respondValidationErrors(w, []models.FieldError{{Field: "/find", Message: "find must not be empty"}})
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	var current models.Post
+	if err := db.FindOne(ctx, db.Active(bson.M{"id": id}), &current); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.RespondWithError(w, http.StatusNotFound, utils.CodePostNotFound, "Post not found")
+			return
+		}
+		log.Printf("Error reading post %d: %v", id, err)
+		utils.Error(w, "Error fetching post", http.StatusInternalServerError)
+		return
+	}
+	afterBodyRead()
+
+	if !strings.Contains(current.Body, req.Find) {
+		respondValidationErrors(w, []models.FieldError{{Field: "/find", Message: "text not found in body"}})
+		return
+	}
+
+	replaced := models.Post{Body: models.NormalizeBody(strings.ReplaceAll(current.Body, req.Find, req.Replace))}
+	if errs := replaced.Validate(); len(errs) > 0 {
+		respondValidationErrors(w, errs)
+		return
+	}
+
+	filter := unmodifiedSinceFilter(r, bson.M{"id": id, "version": current.Version})
+	if current.Version == 0 {
+		// Posts written before versioning have no version field yet
+		filter["version"] = bson.M{"$exists": false}
+	}
+	update := bson.M{
+		"$set": bson.M{"body": replaced.Body, "updated_at": models.Now()},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.Post
+	err := db.PostCol.FindOneAndUpdate(ctx, db.Active(filter), update, opts).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Either the post changed since it was read above, or it is gone or
+		// was modified after If-Unmodified-Since
+		if n, err := db.Count(ctx, db.Active(unmodifiedSinceFilter(r, bson.M{"id": id}))); err == nil && n > 0 {
+			utils.Error(w, "Post was modified concurrently, retry", http.StatusConflict)
+			return
+		}
+		respondNotFoundOrPreconditionFailed(ctx, w, r, id)
+		return
+	}
+	if err != nil {
+		log.Printf("Error replacing body of post %d: %v", id, err)
+		utils.Error(w, "Error updating post", http.StatusInternalServerError)
+		return
+	}
+
+	events.Publish(events.Event{Type: events.PostUpdated, PostID: id, Post: updated})
+	utils.RespondWithJSON(w, updated)
+}
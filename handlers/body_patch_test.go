@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func patchBody(id int, unmodifiedSince time.Time) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPatch, "/posts/1/body", strings.NewReader(`{"find":"old","replace":"new"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if !unmodifiedSince.IsZero() {
+		req.Header.Set("If-Unmodified-Since", unmodifiedSince.UTC().Format(http.TimeFormat))
+	}
+	rec := httptest.NewRecorder()
+	handlePatchPostBody(rec, req, id)
+	return rec
+}
+
+func TestPatchBodyHonorsIfUnmodifiedSince(t *testing.T) {
+	dbtest.Connect(t)
+	updated := time.Now().UTC().Truncate(time.Second)
+	post := models.Post{ID: 1, Body: "old text", CreatedAt: updated, UpdatedAt: updated, Version: 1}
+	if _, err := db.PostCol.InsertOne(context.Background(), post); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := patchBody(1, updated.Add(-time.Hour)); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("modified after the date: status %d, want 412: %s", rec.Code, rec.Body)
+	}
+	if rec := patchBody(1, updated); rec.Code != http.StatusOK {
+		t.Errorf("unmodified since the date: status %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if rec := patchBody(2, updated); rec.Code != http.StatusNotFound {
+		t.Errorf("missing post: status %d, want 404: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestPatchBodyReplacesText(t *testing.T) {
+	dbtest.Connect(t)
+	insertPost(t, models.Post{ID: 1, Body: "old text, old habits", Version: 1})
+
+	rec := patchBody(1, time.Time{})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var stored models.Post
+	if err := db.FindOne(context.Background(), bson.M{"id": 1}, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Body != "new text, new habits" || stored.Version != 2 {
+		t.Errorf("stored body %q version %d, want every match replaced at version 2", stored.Body, stored.Version)
+	}
+}
+
+func TestPatchBodyConflictsWithConcurrentEdit(t *testing.T) {
+	dbtest.Connect(t)
+	insertPost(t, models.Post{ID: 1, Body: "old text", Version: 1})
+
+	// Another writer bumps the version after the handler has read the post
+	afterBodyRead = func() {
+		update := bson.M{"$set": bson.M{"body": "old text, edited"}, "$inc": bson.M{"version": 1}}
+		if _, err := db.PostCol.UpdateOne(context.Background(), bson.M{"id": 1}, update); err != nil {
+			t.Error(err)
+		}
+	}
+	defer func() { afterBodyRead = func() {} }()
+
+	if rec := patchBody(1, time.Time{}); rec.Code != http.StatusConflict {
+		t.Fatalf("status %d, want 409: %s", rec.Code, rec.Body)
+	}
+	var stored models.Post
+	if err := db.FindOne(context.Background(), bson.M{"id": 1}, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Body != "old text, edited" || stored.Version != 2 {
+		t.Errorf("stored body %q version %d, want the concurrent edit kept", stored.Body, stored.Version)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readBody runs readJSONBody on a request and returns the response it wrote,
+// or 200 when the body was accepted
+func readBody(body string, contentType string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/posts", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	if _, ok := readJSONBody(rec, req); ok {
+		rec.WriteHeader(http.StatusOK)
+	}
+	return rec
+}
+
+func TestReadJSONBodyCharsets(t *testing.T) {
+	tests := []struct {
+		contentType string
+		status      int
+	}{
+		{"application/json", http.StatusOK},
+		{"application/json; charset=utf-8", http.StatusOK},
+		{"application/json; charset=UTF-8", http.StatusOK},
+		{"application/json; charset=iso-8859-1", http.StatusUnsupportedMediaType},
+		{"application/json; charset=utf-16", http.StatusUnsupportedMediaType},
+		{"text/plain", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		rec := readBody(`{"body":"x"}`, tt.contentType)
+		if rec.Code != tt.status {
+			t.Errorf("%q: status %d, want %d: %s", tt.contentType, rec.Code, tt.status, rec.Body)
+		}
+	}
+}
+
+func TestReadJSONBodyRejectsInvalidUTF8(t *testing.T) {
+	// Latin-1 "café" sent as if it were UTF-8
+	rec := readBody("{\"body\":\"caf\xe9\"}", "application/json; charset=utf-8")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "not valid UTF-8") {
+		t.Errorf("error %s doesn't mention UTF-8", rec.Body)
+	}
+}
+
+func TestReadJSONBodyStrictContentType(t *testing.T) {
+	defer func(v bool) { requireContentType = v }(requireContentType)
+
+	requireContentType = false
+	if rec := readBody(`{"body":"x"}`, ""); rec.Code != http.StatusOK {
+		t.Errorf("missing Content-Type, lenient: status %d, want 200", rec.Code)
+	}
+	requireContentType = true
+	if rec := readBody(`{"body":"x"}`, ""); rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("missing Content-Type, strict: status %d, want 415", rec.Code)
+	}
+}
+
+func TestReadJSONBodyRejectsTrailingData(t *testing.T) {
+	for _, body := range []string{
+		`{"body":"x"}garbage`,
+		`{"body":"x"}{"body":"y"}`,
+		`{"body":"x"} 1`,
+		`["body"]`,
+	} {
+		rec := readBody(body, "application/json")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, rec.Code)
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), "single JSON object") {
+			t.Errorf("%s: error %s, want it to ask for a single JSON object", body, rec.Body)
+		}
+	}
+
+	if rec := readBody("{\"body\":\"x\"} \n\t", "application/json"); rec.Code != http.StatusOK {
+		t.Errorf("trailing whitespace: status %d, want 200: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestCreateRejectsTrailingData(t *testing.T) {
+	rec := serve(PostsHandler, http.MethodPost, "/posts", `{"body":"x"}garbage`)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "single JSON object") {
+		t.Errorf("status %d: %s, want 400 for trailing data", rec.Code, rec.Body)
+	}
+}
+
+func TestBodyLimitAppliesToEveryWrite(t *testing.T) {
+	dbtest.Connect(t)
+	insertPost(t, models.Post{ID: 1, Body: "old text", Version: 1})
+	defer func(n int64) { maxBodyBytes.Store(n) }(maxBodyBytes.Load())
+	maxBodyBytes.Store(64)
+
+	big := `{"body":"` + strings.Repeat("x", 100) + `"}`
+	bigReplace := `{"find":"old","replace":"` + strings.Repeat("x", 100) + `"}`
+	for _, tt := range []struct {
+		method, target, body string
+	}{
+		{http.MethodPost, "/posts", big},
+		{http.MethodPut, "/posts/1", big},
+		{http.MethodPatch, "/posts/1/body", bigReplace},
+	} {
+		h := PostHandler
+		if tt.target == "/posts" {
+			h = PostsHandler
+		}
+		if rec := serve(h, tt.method, tt.target, tt.body); rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s %s: status %d, want 413: %s", tt.method, tt.target, rec.Code, rec.Body)
+		}
+	}
+
+	// Bodies under the limit still go through
+	if rec := serve(PostHandler, http.MethodPut, "/posts/1", `{"body":"new"}`); rec.Code != http.StatusOK {
+		t.Errorf("small PUT: status %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"go-server/db"
+	"go-server/events"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BulkDeleteResponse struct {
+	DryRun bool  `json:"dryRun"`
+	Count  int   `json:"count"`
+	IDs    []int `json:"ids"`
+}
+
+// handleBulkDeletePosts soft-deletes every post matching the tag filter.
+// ?dryRun=true only reports what would be deleted. Deleting for real needs
+// either dryRun=false or confirm=true, so a bare DELETE can't wipe posts.
+func handleBulkDeletePosts(w http.ResponseWriter, r *http.Request) {
+	q, err := parseListQuery(r)
+	if err != nil {
+		utils.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(q.Tags) == 0 {
+		utils.Error(w, "Bulk delete requires a tag or tags filter", http.StatusBadRequest)
+		return
+	}
+
+	dryRun := true
+	if raw := utils.QueryParam(r, "dryRun"); raw != "" {
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			utils.Error(w, "dryRun must be true or false", http.StatusBadRequest)
+			return
+		}
+	} else if confirm, _ := strconv.ParseBool(utils.QueryParam(r, "confirm")); confirm {
+		dryRun = false
+	} else {
+		utils.Error(w, "Pass dryRun=true to preview or confirm=true to delete", http.StatusBadRequest)
+		return
+	}
+
+	if !requireDB(w) {
+		return
+	}
+
+	postsMu.Lock()
+	defer postsMu.Unlock()
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	matches, err := db.FindPosts(ctx, db.Active(q.filter()), options.Find().
+		SetProjection(bson.M{"id": 1}).
+		SetSort(bson.D{{Key: "id", Value: 1}}))
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+	ids := postIDs(matches)
+
+	if dryRun || len(ids) == 0 {
+		utils.RespondWithJSON(w, BulkDeleteResponse{DryRun: dryRun, Count: len(ids), IDs: ids})
+		return
+	}
+
+	now := models.Now()
+	tombstone := bson.M{
+		"$set": bson.M{"deleted_at": now, "updated_at": now},
+		"$inc": bson.M{"version": 1},
+	}
+	if _, err := db.PostCol.UpdateMany(ctx, db.Active(bson.M{"id": bson.M{"$in": ids}}), tombstone); err != nil {
+		log.Printf("Error bulk deleting posts: %v", err)
+		utils.Error(w, "Error deleting posts", http.StatusInternalServerError)
+		return
+	}
+
+	events.Publish(events.Event{Type: events.PostsDeleted, PostIDs: ids})
+	utils.RespondWithJSON(w, BulkDeleteResponse{DryRun: false, Count: len(ids), IDs: ids})
+}
+
+func postIDs(posts []models.Post) []int {
+	ids := make([]int, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	return ids
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-server/cache"
+	"go-server/cache/cachetest"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func bulkDelete(t *testing.T, query string) (int, BulkDeleteResponse) {
+	t.Helper()
+	rec := serve(PostsHandler, http.MethodDelete, "/posts?"+query, "")
+	var resp BulkDeleteResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func activeCount(t *testing.T) int64 {
+	t.Helper()
+	n, err := db.Count(context.Background(), db.Active(bson.M{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func insertTaggedPosts(t *testing.T) {
+	t.Helper()
+	insertPost(t, models.Post{ID: 1, Body: "b", Tags: []string{"foo"}, Version: 1})
+	insertPost(t, models.Post{ID: 2, Body: "b", Tags: []string{"bar"}, Version: 1})
+	insertPost(t, models.Post{ID: 3, Body: "b", Tags: []string{"foo", "bar"}, Version: 1})
+}
+
+func TestBulkDeleteDryRunDeletesNothing(t *testing.T) {
+	dbtest.Connect(t)
+	insertTaggedPosts(t)
+
+	status, resp := bulkDelete(t, "tag=foo&dryRun=true")
+	if status != http.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	if !resp.DryRun || resp.Count != 2 || !slices.Equal(resp.IDs, []int{1, 3}) {
+		t.Errorf("dry run reported %+v, want ids [1 3]", resp)
+	}
+	if n := activeCount(t); n != 3 {
+		t.Errorf("%d posts left after a dry run, want 3", n)
+	}
+}
+
+func TestBulkDeleteNeedsDryRunOrConfirm(t *testing.T) {
+	dbtest.Connect(t)
+	insertTaggedPosts(t)
+
+	if status, _ := bulkDelete(t, "tag=foo"); status != http.StatusBadRequest {
+		t.Errorf("bare delete: status %d, want 400", status)
+	}
+	if n := activeCount(t); n != 3 {
+		t.Fatalf("%d posts left after a bare delete, want 3", n)
+	}
+
+	status, resp := bulkDelete(t, "tag=foo&confirm=true")
+	if status != http.StatusOK || resp.DryRun || !slices.Equal(resp.IDs, []int{1, 3}) {
+		t.Fatalf("confirmed delete: status %d %+v, want ids [1 3] deleted", status, resp)
+	}
+	if n := activeCount(t); n != 1 {
+		t.Errorf("%d posts left, want only post 2", n)
+	}
+}
+
+func TestBulkDeleteEvictsCachedPosts(t *testing.T) {
+	dbtest.Connect(t)
+	mr := cachetest.Start(t)
+	cacheEvents.Do(cache.SubscribeToPostEvents)
+	insertTaggedPosts(t)
+	for _, id := range []int{1, 2, 3} {
+		serve(PostHandler, http.MethodGet, fmt.Sprintf("/posts/%d", id), "")
+	}
+	listIDs(t, "limit=10")
+
+	if status, _ := bulkDelete(t, "tag=foo&confirm=true"); status != http.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	var left []string
+	for _, k := range mr.Keys() {
+		if strings.HasPrefix(k, "post:") || strings.HasPrefix(k, "posts:list:") {
+			left = append(left, k)
+		}
+	}
+	if !slices.Equal(left, []string{cache.BuildPostKey(2)}) {
+		t.Errorf("cached posts and lists %v, want only post 2", left)
+	}
+}
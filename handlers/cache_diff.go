@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"go-server/cache"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type FieldDiff struct {
+	Field    string      `json:"field"`
+	Cache    interface{} `json:"cache"`
+	Database interface{} `json:"database"`
+}
+
+type CacheDiffResponse struct {
+	ID          int          `json:"id"`
+	InCache     bool         `json:"inCache"`
+	InDatabase  bool         `json:"inDatabase"`
+	Stale       bool         `json:"stale"`
+	Differences []FieldDiff  `json:"differences"`
+	Cache       *models.Post `json:"cache,omitempty"`
+	Database    *models.Post `json:"database,omitempty"`
+}
+
+// Handling function for /admin/posts/{id}/diff endpoint
+// Compares a post's cached copy with MongoDB, bypassing the cache-filling path
+func AdminPostDiffHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/posts/"), "/")
+	if len(parts) != 2 || parts[1] != "diff" {
+		utils.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		utils.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	resp := CacheDiffResponse{ID: id, Differences: []FieldDiff{}}
+	if cached, _, found := cache.GetCachedPost(id); found {
+		resp.InCache, resp.Cache = true, &cached
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	var stored models.Post
+	err = db.FindOne(ctx, db.Active(bson.M{"id": id}), &stored)
+	switch {
+	case err == nil:
+		resp.InDatabase, resp.Database = true, &stored
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		log.Printf("Error fetching post %d for diff: %v", id, err)
+		utils.Error(w, "Error fetching post", http.StatusInternalServerError)
+		return
+	}
+
+	if resp.InCache && resp.InDatabase {
+		resp.Differences = diffPosts(*resp.Cache, *resp.Database)
+	}
+	// A cached post that's gone from the database is the worst kind of stale
+	resp.Stale = len(resp.Differences) > 0 || (resp.InCache && !resp.InDatabase)
+	utils.RespondWithJSON(w, resp)
+}
+
+// diffPosts lists the fields whose values differ, by JSON name
+func diffPosts(cached, stored models.Post) []FieldDiff {
+	diffs := []FieldDiff{}
+	add := func(field string, a, b interface{}, equal bool) {
+		if !equal {
+			diffs = append(diffs, FieldDiff{Field: field, Cache: a, Database: b})
+		}
+	}
+	add("objectId", cached.ObjectID, stored.ObjectID, cached.ObjectID == stored.ObjectID)
+	add("body", cached.Body, stored.Body, cached.Body == stored.Body)
+	// slices.Equal treats nil and empty alike: the cache drops empty tags
+	// (omitempty) while MongoDB hands back the stored []
+	add("tags", cached.Tags, stored.Tags, slices.Equal(cached.Tags, stored.Tags))
+	add("createdAt", cached.CreatedAt.UTC(), stored.CreatedAt.UTC(), cached.CreatedAt.Equal(stored.CreatedAt))
+	add("updatedAt", cached.UpdatedAt.UTC(), stored.UpdatedAt.UTC(), cached.UpdatedAt.Equal(stored.UpdatedAt))
+	add("version", cached.Version, stored.Version, cached.Version == stored.Version)
+	add("views", cached.Views, stored.Views, cached.Views == stored.Views)
+	return diffs
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-server/cache"
+	"go-server/cache/cachetest"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDiffPostsTreatsNilAndEmptyTagsAsEqual(t *testing.T) {
+	now := time.Now()
+	cached := models.Post{ID: 1, Body: "b", CreatedAt: now, UpdatedAt: now, Version: 2}
+	stored := cached
+	stored.Tags = []string{}
+
+	if diffs := diffPosts(cached, stored); len(diffs) != 0 {
+		t.Errorf("nil and empty tags reported as %+v", diffs)
+	}
+}
+
+func TestDiffPostsReportsChangedFields(t *testing.T) {
+	now := time.Now()
+	cached := models.Post{ID: 1, Body: "old", Tags: []string{"a"}, CreatedAt: now, UpdatedAt: now, Version: 1}
+	stored := cached
+	stored.Body, stored.Tags, stored.UpdatedAt, stored.Version = "new", []string{"a", "b"}, now.Add(time.Second), 2
+
+	var fields []string
+	for _, d := range diffPosts(cached, stored) {
+		fields = append(fields, d.Field)
+	}
+	want := []string{"body", "tags", "updatedAt", "version"}
+	if len(fields) != len(want) {
+		t.Fatalf("differences in %v, want %v", fields, want)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("differences in %v, want %v", fields, want)
+			break
+		}
+	}
+}
+
+// insertPost stores p in MongoDB the way the handlers would read it back
+func insertPost(t *testing.T, p models.Post) models.Post {
+	t.Helper()
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = models.Now()
+		p.UpdatedAt = p.CreatedAt
+	}
+	if _, err := db.PostCol.InsertOne(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+	var stored models.Post
+	if err := db.FindOne(context.Background(), bson.M{"id": p.ID}, &stored); err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+func getPostDiff(t *testing.T, id string) CacheDiffResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	AdminPostDiffHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/posts/"+id+"/diff", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp CacheDiffResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestPostDiffReportsInjectedStaleEntry(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+
+	stored := insertPost(t, models.Post{ID: 1, Body: "current", Version: 2})
+	stale := stored
+	stale.Body, stale.Version = "outdated", 1
+	cache.CachePost(stale)
+
+	resp := getPostDiff(t, "1")
+	if !resp.Stale {
+		t.Fatal("injected stale entry not reported")
+	}
+	if len(resp.Differences) != 2 || resp.Differences[0].Field != "body" || resp.Differences[1].Field != "version" {
+		t.Errorf("differences %+v, want body and version", resp.Differences)
+	}
+}
+
+func TestPostDiffEmptyTagsNotStale(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+
+	// An update with {"tags":[]} stores an empty array; the struct's
+	// omitempty would leave the field out, so insert the document directly
+	now := models.Now()
+	doc := bson.M{"id": 1, "body": "b", "tags": bson.A{}, "created_at": now, "updated_at": now, "version": 1}
+	if _, err := db.PostCol.InsertOne(context.Background(), doc); err != nil {
+		t.Fatal(err)
+	}
+	var stored models.Post
+	if err := db.FindOne(context.Background(), bson.M{"id": 1}, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Tags == nil {
+		t.Fatal("stored tags decoded as nil, want an empty slice")
+	}
+	cache.CachePost(stored)
+
+	if resp := getPostDiff(t, "1"); resp.Stale {
+		t.Errorf("fresh entry reported stale: %+v", resp.Differences)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"go-server/cache/cachetest"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/middleware"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func cacheTrace(h http.HandlerFunc, target string) string {
+	rec := httptest.NewRecorder()
+	middleware.CacheTrace(h).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec.Header().Get("X-Cache-Trace")
+}
+
+func TestCacheTraceRecordsDecisions(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+	insertPost(t, models.Post{ID: 1, Body: "post", Version: 1})
+
+	// Redis misses, MongoDB has the post and it is written back
+	if got, want := cacheTrace(PostHandler, "/posts/1"), "redis=miss, db=hit, redis=store"; got != want {
+		t.Errorf("cold read trace %q, want %q", got, want)
+	}
+	if got, want := cacheTrace(PostHandler, "/posts/1"), "redis=hit"; got != want {
+		t.Errorf("warm read trace %q, want %q", got, want)
+	}
+
+	// A weak read answers from Redis and refreshes in the background
+	useReadMode(t, ReadModeCacheFirstAsync)
+	if _, err := db.PostCol.UpdateOne(context.Background(), bson.M{"id": 1}, bson.M{"$set": bson.M{"body": "fresh"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cacheTrace(PostHandler, "/posts/1"), "redis=hit, refresh=async"; got != want {
+		t.Errorf("async read trace %q, want %q", got, want)
+	}
+	waitForCache(t, 1, func(p models.Post, found bool) bool { return found && p.Body == "fresh" })
+}
+
+func TestCacheTraceHiddenInProduction(t *testing.T) {
+	cachetest.Start(t)
+	t.Setenv("ENV", "production")
+	t.Setenv("ADMIN_TOKEN", "secret")
+
+	if got := cacheTrace(PostsHandler, "/posts?limit=1"); got != "" {
+		t.Errorf("X-Cache-Trace = %q without the admin token", got)
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-server/cache"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultVerifyLimit = 1000
+	maxVerifyLimit     = 10000
+	verifyBatchSize    = 100
+)
+
+// VerifyProgress is one line of the /admin/cache/verify stream. Progress
+// lines come after each batch; the last line has Done set.
+type VerifyProgress struct {
+	Scanned  int    `json:"scanned"`
+	Stale    int    `json:"stale"`
+	Repaired int    `json:"repaired"`
+	StaleIDs []int  `json:"staleIds,omitempty"`
+	Done     bool   `json:"done"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Handling function for /admin/cache/verify endpoint
+// Compares up to ?limit= cached posts with MongoDB and reports stale entries;
+// ?repair=true also fixes them. Progress is streamed as NDJSON.
+func AdminCacheVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := defaultVerifyLimit
+	if raw := utils.QueryParam(r, "limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxVerifyLimit {
+			utils.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxVerifyLimit), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	repair, _ := strconv.ParseBool(utils.QueryParam(r, "repair"))
+
+	if !cache.Enabled() {
+		utils.RespondWithError(w, http.StatusServiceUnavailable, utils.CodeCacheUnavailable, "Cache unavailable")
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	emit := func(p VerifyProgress) {
+		enc.Encode(p)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	var progress VerifyProgress
+	var cursor uint64
+	// SCAN may return a key more than once
+	seen := make(map[int]bool)
+	for progress.Scanned < limit {
+		ids, next, err := cache.ScanPostIDs(cursor, verifyBatchSize)
+		if err != nil {
+			log.Printf("Error scanning cached posts: %v", err)
+			progress.Error = "error scanning cache"
+			break
+		}
+		ids = unseenIDs(ids, seen, limit-progress.Scanned)
+		if err := verifyBatch(r.Context(), ids, repair, &progress); err != nil {
+			log.Printf("Error verifying cached posts: %v", err)
+			progress.Error = "error reading posts"
+			break
+		}
+		emit(progress)
+
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+
+	progress.Done = true
+	emit(progress)
+}
+
+// verifyBatch checks one batch of cached ids against a single MongoDB query
+func verifyBatch(parent context.Context, ids []int, repair bool, progress *VerifyProgress) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := dbContext(parent)
+	defer cancel()
+
+	stored, err := db.FindPosts(ctx, db.Active(bson.M{"id": bson.M{"$in": ids}}), options.Find())
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]models.Post, len(stored))
+	for _, p := range stored {
+		byID[p.ID] = p
+	}
+
+	for _, id := range ids {
+		cached, _, found := cache.GetCachedPost(id)
+		if !found {
+			// Expired between SCAN and GET
+			continue
+		}
+		progress.Scanned++
+
+		fresh, inDB := byID[id]
+		if inDB && len(diffPosts(cached, fresh)) == 0 {
+			continue
+		}
+		progress.Stale++
+		progress.StaleIDs = append(progress.StaleIDs, id)
+		if !repair {
+			continue
+		}
+		if inDB {
+			cache.CachePost(fresh)
+		} else if err := cache.DropPost(id); err != nil {
+			log.Printf("Error dropping stale cache entry for post %d: %v", id, err)
+			continue
+		}
+		progress.Repaired++
+	}
+	return nil
+}
+
+// unseenIDs returns up to max of the ids not already in seen, adding them to it
+func unseenIDs(ids []int, seen map[int]bool, max int) []int {
+	fresh := ids[:0]
+	for _, id := range ids {
+		if len(fresh) == max {
+			break
+		}
+		if !seen[id] {
+			seen[id] = true
+			fresh = append(fresh, id)
+		}
+	}
+	return fresh
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"go-server/cache"
+	"go-server/cache/cachetest"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUnseenIDs(t *testing.T) {
+	seen := map[int]bool{}
+	if got := unseenIDs([]int{1, 2, 2, 3}, seen, 10); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("first batch = %v, want [1 2 3]", got)
+	}
+	// SCAN handing back keys from an earlier batch
+	if got := unseenIDs([]int{3, 4, 1, 5}, seen, 1); !slices.Equal(got, []int{4}) {
+		t.Errorf("second batch = %v, want [4]", got)
+	}
+	// 5 was cut by the limit, not verified, so it is still unseen
+	if got := unseenIDs([]int{5}, seen, 10); !slices.Equal(got, []int{5}) {
+		t.Errorf("third batch = %v, want [5]", got)
+	}
+}
+
+// runVerify runs /admin/cache/verify and returns its final progress line
+func runVerify(t *testing.T, repair bool) VerifyProgress {
+	t.Helper()
+	target := "/admin/cache/verify"
+	if repair {
+		target += "?repair=true"
+	}
+	rec := httptest.NewRecorder()
+	AdminCacheVerifyHandler(rec, httptest.NewRequest(http.MethodPost, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	var last VerifyProgress
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		if err := json.Unmarshal(scanner.Bytes(), &last); err != nil {
+			t.Fatalf("decoding %q: %v", scanner.Text(), err)
+		}
+	}
+	if !last.Done {
+		t.Fatal("stream ended without a done line")
+	}
+	return last
+}
+
+func TestCacheVerifyRepairConverges(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+	ctx := context.Background()
+	now := models.Now()
+
+	// 1 is current, 2 has a stale body cached, 3 is cached but deleted, and 4
+	// is stored with empty tags, which the cache leaves out
+	docs := []interface{}{
+		bson.M{"id": 1, "body": "one", "created_at": now, "updated_at": now, "version": 1},
+		bson.M{"id": 2, "body": "two", "created_at": now, "updated_at": now, "version": 2},
+		bson.M{"id": 4, "body": "four", "tags": bson.A{}, "created_at": now, "updated_at": now, "version": 1},
+	}
+	if _, err := db.PostCol.InsertMany(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int{1, 2, 4} {
+		var p models.Post
+		if err := db.FindOne(ctx, bson.M{"id": id}, &p); err != nil {
+			t.Fatal(err)
+		}
+		if id == 2 {
+			p.Body, p.Version = "old two", 1
+		}
+		cache.CachePost(p)
+	}
+	cache.CachePost(models.Post{ID: 3, Body: "three", CreatedAt: now, UpdatedAt: now, Version: 1})
+
+	first := runVerify(t, true)
+	slices.Sort(first.StaleIDs)
+	if first.Scanned != 4 || first.Stale != 2 || first.Repaired != 2 || !slices.Equal(first.StaleIDs, []int{2, 3}) {
+		t.Errorf("first run = %+v, want 4 scanned and posts 2 and 3 stale and repaired", first)
+	}
+
+	second := runVerify(t, false)
+	if second.Stale != 0 {
+		t.Errorf("second run still finds stale posts %v", second.StaleIDs)
+	}
+	if second.Scanned != 3 {
+		t.Errorf("second run scanned %d, want 3 once the deleted post is dropped", second.Scanned)
+	}
+}
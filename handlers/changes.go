@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ChangesResponse struct {
+	Changes    []models.Post `json:"changes"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+	ServerTime string        `json:"serverTime"`
+}
+
+// changeCursor is the position of the last change a client has seen. The id
+// breaks ties between posts updated in the same instant.
+type changeCursor struct {
+	UpdatedAt time.Time
+	ID        int
+}
+
+// Handling function for /posts/changes endpoint
+// Returns posts updated after ?since=<rfc3339>, soft-deleted tombstones
+// included, oldest change first. Clients page through with ?cursor= and use
+// serverTime as the next since watermark.
+func PostChangesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	// Captured before querying so nothing written during the query is skipped.
+	// Cutting it to milliseconds only moves the watermark back, never past a change.
+	serverTime := models.Now()
+
+	var filter bson.M
+	if raw := utils.QueryParam(r, "cursor"); raw != "" {
+		cursor, err := decodeChangeCursor(raw)
+		if err != nil {
+			utils.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		filter = bson.M{"$or": bson.A{
+			bson.M{"updated_at": bson.M{"$gt": cursor.UpdatedAt}},
+			bson.M{"updated_at": cursor.UpdatedAt, "id": bson.M{"$gt": cursor.ID}},
+		}}
+	} else {
+		since, err := time.Parse(time.RFC3339Nano, utils.QueryParam(r, "since"))
+		if err != nil {
+			utils.Error(w, "since must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter = bson.M{"updated_at": bson.M{"$gt": since}}
+	}
+
+	limit, _ := utils.ParsePaginationParams(r)
+	// One extra row tells us whether another page exists
+	findOptions := options.Find().
+		SetLimit(int64(limit + 1)).
+		SetSort(bson.D{{Key: "updated_at", Value: 1}, {Key: "id", Value: 1}})
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	release, ok := acquireCursorSlot(w)
+	if !ok {
+		return
+	}
+	defer release()
+
+	changes, err := db.FindPosts(ctx, filter, findOptions)
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+
+	resp := ChangesResponse{Changes: changes, ServerTime: models.FormatTimestamp(serverTime)}
+	if len(changes) > limit {
+		resp.Changes = changes[:limit]
+		last := resp.Changes[limit-1]
+		resp.NextCursor = encodeChangeCursor(changeCursor{UpdatedAt: last.UpdatedAt, ID: last.ID})
+	}
+	if resp.Changes == nil {
+		resp.Changes = []models.Post{}
+	}
+	utils.RespondWithJSON(w, resp)
+}
+
+func encodeChangeCursor(c changeCursor) string {
+	raw := fmt.Sprintf("%d:%d", c.UpdatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeChangeCursor(s string) (changeCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return changeCursor{}, err
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return changeCursor{}, errors.New("malformed cursor")
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return changeCursor{}, err
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return changeCursor{}, err
+	}
+	return changeCursor{UpdatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/db/dbtest"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func getChanges(t *testing.T, query string) ChangesResponse {
+	t.Helper()
+	rec := serve(PostChangesHandler, http.MethodGet, "/posts/changes?"+query, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp ChangesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func since(watermark string) string {
+	return "since=" + url.QueryEscape(watermark)
+}
+
+func TestChangeFeedReflectsWrites(t *testing.T) {
+	dbtest.Connect(t)
+
+	start := getChanges(t, since("2000-01-01T00:00:00Z"))
+	if len(start.Changes) != 0 {
+		t.Fatalf("empty database has changes %+v", start.Changes)
+	}
+	// Timestamps have millisecond resolution; keep each step in its own
+	time.Sleep(2 * time.Millisecond)
+
+	for _, body := range []string{`{"body":"first"}`, `{"body":"second"}`} {
+		if rec := serve(PostsHandler, http.MethodPost, "/posts", body); rec.Code != http.StatusCreated {
+			t.Fatalf("create: status %d: %s", rec.Code, rec.Body)
+		}
+	}
+	created := getChanges(t, since(start.ServerTime))
+	if len(created.Changes) != 2 || created.Changes[0].Body != "first" || created.Changes[1].Body != "second" {
+		t.Fatalf("after creating: changes %+v, want first and second", created.Changes)
+	}
+	first, second := created.Changes[0].ID, created.Changes[1].ID
+	time.Sleep(2 * time.Millisecond)
+
+	if rec := serve(PostHandler, http.MethodPut, "/posts/"+strconv.Itoa(first), `{"body":"first, edited"}`); rec.Code != http.StatusOK {
+		t.Fatalf("edit: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(PostHandler, http.MethodDelete, "/posts/"+strconv.Itoa(second), ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status %d: %s", rec.Code, rec.Body)
+	}
+	changed := getChanges(t, since(created.ServerTime))
+	if len(changed.Changes) != 2 {
+		t.Fatalf("after editing and deleting: changes %+v, want both posts", changed.Changes)
+	}
+	edited, deleted := changed.Changes[0], changed.Changes[1]
+	if edited.ID != first || edited.Body != "first, edited" || edited.DeletedAt != nil {
+		t.Errorf("edit reported as %+v", edited)
+	}
+	if deleted.ID != second || deleted.DeletedAt == nil {
+		t.Errorf("delete reported as %+v, want a tombstone", deleted)
+	}
+
+	if rest := getChanges(t, since(changed.ServerTime)); len(rest.Changes) != 0 {
+		t.Errorf("caught-up client still gets %+v", rest.Changes)
+	}
+}
+
+func TestChangeFeedPages(t *testing.T) {
+	dbtest.Connect(t)
+	for _, body := range []string{`{"body":"a"}`, `{"body":"b"}`, `{"body":"c"}`} {
+		serve(PostsHandler, http.MethodPost, "/posts", body)
+	}
+
+	var bodies []string
+	query := since("2000-01-01T00:00:00Z") + "&limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("cursor never ran out")
+		}
+		page := getChanges(t, query)
+		for _, p := range page.Changes {
+			bodies = append(bodies, p.Body)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query = "limit=2&cursor=" + page.NextCursor
+	}
+	if len(bodies) != 3 || bodies[0] != "a" || bodies[1] != "b" || bodies[2] != "c" {
+		t.Errorf("paged through %v, want [a b c]", bodies)
+	}
+}
+
+func TestChangeFeedRejectsBadParams(t *testing.T) {
+	dbtest.Connect(t)
+	for _, query := range []string{"since=yesterday", "", "cursor=not-a-cursor!"} {
+		if rec := serve(PostChangesHandler, http.MethodGet, "/posts/changes?"+query, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: status %d, want 400", query, rec.Code)
+		}
+	}
+}
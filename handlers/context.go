@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"net/http"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultContextRadius = 2
+	maxContextRadius     = 20
+)
+
+type PostContextResponse struct {
+	Post   models.Post   `json:"post"`
+	Before []models.Post `json:"before"`
+	After  []models.Post `json:"after"`
+}
+
+// handleGetPostContext returns a post with up to radius neighbours on each
+// side by id, so a thread view needs one round trip. Deleted posts are
+// skipped, and near either end of the collection there are simply fewer.
+func handleGetPostContext(w http.ResponseWriter, r *http.Request, id int) {
+	radius := defaultContextRadius
+	if raw := utils.QueryParam(r, "radius"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 || parsed > maxContextRadius {
+			utils.Error(w, fmt.Sprintf("radius must be between 0 and %d", maxContextRadius), http.StatusBadRequest)
+			return
+		}
+		radius = parsed
+	}
+
+	if !requireDB(w) {
+		return
+	}
+
+	p, err := fetchPost(r.Context(), id)
+	if err != nil {
+		respondFetchError(w, err)
+		return
+	}
+
+	resp := PostContextResponse{Post: p, Before: []models.Post{}, After: []models.Post{}}
+	if radius > 0 {
+		ctx, cancel := dbContext(r.Context())
+		defer cancel()
+
+		before, err := db.FindPosts(ctx, db.Active(bson.M{"id": bson.M{"$lt": id}}),
+			options.Find().SetSort(bson.D{{Key: "id", Value: -1}}).SetLimit(int64(radius)))
+		if err != nil {
+			respondListError(w, err)
+			return
+		}
+		after, err := db.FindPosts(ctx, db.Active(bson.M{"id": bson.M{"$gt": id}}),
+			options.Find().SetSort(bson.D{{Key: "id", Value: 1}}).SetLimit(int64(radius)))
+		if err != nil {
+			respondListError(w, err)
+			return
+		}
+
+		// Nearest-first from the query; flip so both lists read in id order
+		for i := len(before) - 1; i >= 0; i-- {
+			resp.Before = append(resp.Before, before[i])
+		}
+		resp.After = append(resp.After, after...)
+	}
+	utils.RespondWithJSON(w, resp)
+}
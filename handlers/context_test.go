@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestPostContextNeighbours(t *testing.T) {
+	dbtest.Connect(t)
+	deleted := models.Now()
+	for id := 1; id <= 7; id++ {
+		p := models.Post{ID: id, Body: "post", Version: 1}
+		if id == 3 {
+			p.DeletedAt = &deleted
+		}
+		insertPost(t, p)
+	}
+
+	ids := func(posts []models.Post) []int {
+		out := []int{}
+		for _, p := range posts {
+			out = append(out, p.ID)
+		}
+		return out
+	}
+	tests := []struct {
+		name          string
+		target        string
+		before, after []int
+	}{
+		{"first", "/posts/1/context", []int{}, []int{2, 4}},
+		{"middle", "/posts/4/context?radius=2", []int{1, 2}, []int{5, 6}},
+		{"last", "/posts/7/context?radius=3", []int{4, 5, 6}, []int{}},
+		{"no radius", "/posts/4/context?radius=0", []int{}, []int{}},
+	}
+	for _, tt := range tests {
+		rec := serve(PostHandler, http.MethodGet, tt.target, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tt.name, rec.Code, rec.Body)
+		}
+		var resp PostContextResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(resp.Before); !slices.Equal(got, tt.before) {
+			t.Errorf("%s: before %v, want %v", tt.name, got, tt.before)
+		}
+		if got := ids(resp.After); !slices.Equal(got, tt.after) {
+			t.Errorf("%s: after %v, want %v", tt.name, got, tt.after)
+		}
+	}
+
+	if rec := serve(PostHandler, http.MethodGet, "/posts/3/context", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("deleted post: status %d, want 404", rec.Code)
+	}
+	if rec := serve(PostHandler, http.MethodGet, "/posts/4/context?radius=21", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("radius over the cap: status %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSnapshotInterval = time.Hour
+	defaultHistoryPoints    = 24
+	maxHistoryPoints        = 1000
+)
+
+type CountHistoryPoint struct {
+	db.CountSnapshot
+	// Delta is the change since the previous point; the first point has none
+	Delta *int64 `json:"delta,omitempty"`
+}
+
+type CountHistoryResponse struct {
+	Interval string              `json:"interval"`
+	Points   []CountHistoryPoint `json:"points"`
+}
+
+// countSnapshots records the post count every COUNT_SNAPSHOT_INTERVAL. It is
+// started and stopped as a lifecycle component.
+var countSnapshots struct {
+	interval time.Duration
+	stop     chan struct{}
+	done     sync.WaitGroup
+}
+
+// StartCountSnapshots reads COUNT_SNAPSHOT_INTERVAL (default 1h, 0 disables)
+// and starts taking snapshots, the first one straight away
+func StartCountSnapshots(ctx context.Context) error {
+	interval := defaultSnapshotInterval
+	if raw := os.Getenv("COUNT_SNAPSHOT_INTERVAL"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("invalid COUNT_SNAPSHOT_INTERVAL %q: must be a non-negative duration", raw)
+		}
+		interval = parsed
+	}
+	countSnapshots.interval = interval
+	if interval == 0 {
+		return nil
+	}
+
+	stop := make(chan struct{})
+	countSnapshots.stop = stop
+	countSnapshots.done.Add(1)
+	go func() {
+		defer countSnapshots.done.Done()
+		runCountSnapshots(interval, realSnapshotClock, stop, takeCountSnapshot)
+	}()
+	return nil
+}
+
+// snapshotClock is the time source for the snapshotter, replaced in tests
+type snapshotClock struct {
+	now func() time.Time
+	// ticker returns a channel that fires every d and a function stopping it
+	ticker func(d time.Duration) (<-chan time.Time, func())
+}
+
+var realSnapshotClock = snapshotClock{
+	now: models.Now,
+	ticker: func(d time.Duration) (<-chan time.Time, func()) {
+		t := time.NewTicker(d)
+		return t.C, t.Stop
+	},
+}
+
+// runCountSnapshots calls record straight away and then on every tick until
+// stop is closed, each time with the current time rounded down to interval
+func runCountSnapshots(interval time.Duration, clock snapshotClock, stop <-chan struct{}, record func(at time.Time)) {
+	ticks, stopTicker := clock.ticker(interval)
+	defer stopTicker()
+	for {
+		record(clock.now().Truncate(interval))
+		select {
+		case <-ticks:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// StopCountSnapshots stops the snapshotter, waiting for a snapshot in
+// progress to finish
+func StopCountSnapshots(ctx context.Context) error {
+	if countSnapshots.stop == nil {
+		return nil
+	}
+	close(countSnapshots.stop)
+	countSnapshots.stop = nil
+
+	finished := make(chan struct{})
+	go func() {
+		countSnapshots.done.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func takeCountSnapshot(at time.Time) {
+	// MongoDB may still be connecting, or absent in degraded mode
+	if db.SnapshotCol == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+	if _, err := db.RecordCountSnapshot(ctx, at); err != nil {
+		log.Printf("Error recording post count snapshot: %v", err)
+	}
+}
+
+// Handling function for /posts/count/history endpoint
+// The last ?points= post count snapshots, oldest first, each with the change
+// since the one before
+func PostCountHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	points := defaultHistoryPoints
+	if raw := utils.QueryParam(r, "points"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxHistoryPoints {
+			utils.Error(w, fmt.Sprintf("points must be between 1 and %d", maxHistoryPoints), http.StatusBadRequest)
+			return
+		}
+		points = parsed
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+	snaps, err := db.RecentCountSnapshots(ctx, int64(points))
+	if err != nil {
+		log.Printf("Error reading post count snapshots: %v", err)
+		utils.Error(w, "Error fetching count history", http.StatusInternalServerError)
+		return
+	}
+
+	resp := CountHistoryResponse{Interval: countSnapshots.interval.String(), Points: make([]CountHistoryPoint, len(snaps))}
+	for i, snap := range snaps {
+		resp.Points[i].CountSnapshot = snap
+		if i > 0 {
+			delta := snap.Count - snaps[i-1].Count
+			resp.Points[i].Delta = &delta
+		}
+	}
+	utils.RespondWithJSON(w, resp)
+}
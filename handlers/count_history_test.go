@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// fakeTicker hands the snapshotter a tick channel the test fires by hand
+type fakeTicker struct {
+	now     time.Time
+	ticks   chan time.Time
+	stopped chan struct{}
+}
+
+func (f *fakeTicker) snapshotClock() snapshotClock {
+	return snapshotClock{
+		now: func() time.Time { return f.now },
+		ticker: func(time.Duration) (<-chan time.Time, func()) {
+			return f.ticks, func() { close(f.stopped) }
+		},
+	}
+}
+
+func TestCountSnapshotsRoundDownToInterval(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 37, 12, 0, time.UTC)
+	clock := &fakeTicker{now: start, ticks: make(chan time.Time), stopped: make(chan struct{})}
+	stop := make(chan struct{})
+	recorded := make(chan time.Time)
+	go runCountSnapshots(time.Hour, clock.snapshotClock(), stop, func(at time.Time) { recorded <- at })
+
+	// The first snapshot is taken at startup, filed under the current hour
+	if at := <-recorded; !at.Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("startup snapshot at %s, want 10:00", at)
+	}
+	for _, want := range []time.Time{
+		time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	} {
+		clock.now = clock.now.Add(time.Hour)
+		clock.ticks <- clock.now
+		if at := <-recorded; !at.Equal(want) {
+			t.Errorf("snapshot at %s, want %s", at, want)
+		}
+	}
+
+	close(stop)
+	select {
+	case <-clock.stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ticker not stopped after stop was closed")
+	}
+}
+
+func TestCountSnapshotsOnePerSlot(t *testing.T) {
+	dbtest.Connect(t)
+	ctx := context.Background()
+	// A recent slot, so the TTL monitor leaves it alone
+	slot := time.Now().UTC().Truncate(time.Hour)
+
+	// Two instances, or a restart, recording the same slot
+	takeCountSnapshot(slot)
+	insertPost(t, models.Post{ID: 1, Body: "b", Version: 1})
+	takeCountSnapshot(slot)
+	takeCountSnapshot(slot.Add(time.Hour))
+
+	n, err := db.SnapshotCol.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("%d snapshots stored, want one per slot", n)
+	}
+
+	rec := httptest.NewRecorder()
+	PostCountHistoryHandler(rec, httptest.NewRequest(http.MethodGet, "/posts/count/history", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp CountHistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	// The slot keeps the count it was first recorded with
+	if len(resp.Points) != 2 || resp.Points[0].Count != 0 || resp.Points[1].Count != 1 {
+		t.Errorf("history %+v, want counts 0 then 1", resp.Points)
+	}
+}
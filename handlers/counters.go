@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"go-server/db"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var counterName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type CounterResponse struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+// Handling function for /admin/counters/{name}/next endpoint
+// Atomically advances a named sequence, for other services that need unique
+// increasing numbers. Concurrent calls never see the same value.
+func AdminCounterNextHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/admin/counters/"), "/")
+	if action != "next" {
+		utils.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if !counterName.MatchString(name) {
+		utils.Error(w, "Counter name must be 1-64 letters, digits, '_' or '-'", http.StatusBadRequest)
+		return
+	}
+	if db.IsReservedCounter(name) {
+		utils.Error(w, "Counter "+name+" is reserved", http.StatusForbidden)
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	value, err := db.NextSequence(ctx, name)
+	if err != nil {
+		log.Printf("Error advancing counter %q: %v", name, err)
+		utils.Error(w, "Error advancing counter", http.StatusInternalServerError)
+		return
+	}
+	utils.RespondWithJSON(w, CounterResponse{Name: name, Value: value})
+}
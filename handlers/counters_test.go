@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/db/dbtest"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func counterNext(t *testing.T, name string) (int, CounterResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	AdminCounterNextHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/counters/"+name+"/next", nil))
+	var resp CounterResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Errorf("decoding response: %v", err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestCounterNextConcurrentIsContiguous(t *testing.T) {
+	dbtest.Connect(t)
+
+	// Every caller starts on the counter's first use, so the upsert races too
+	const callers = 50
+	var wg sync.WaitGroup
+	codes := make([]int, callers)
+	values := make([]int, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var resp CounterResponse
+			codes[i], resp = counterNext(t, "invoices")
+			values[i] = resp.Value
+		}(i)
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Fatalf("call %d: status %d", i, code)
+		}
+	}
+	slices.Sort(values)
+	for i, v := range values {
+		if v != i+1 {
+			t.Fatalf("values %v, want 1 to %d each once", values, callers)
+		}
+	}
+}
+
+func TestCounterNextRejectsReservedAndInvalidNames(t *testing.T) {
+	for name, want := range map[string]int{
+		"post_id":   http.StatusForbidden,
+		"bad.name":  http.StatusBadRequest,
+		"star*name": http.StatusBadRequest,
+	} {
+		if code, _ := counterNext(t, name); code != want {
+			t.Errorf("%q: status %d, want %d", name, code, want)
+		}
+	}
+}
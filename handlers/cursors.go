@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"go-server/utils"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+const defaultCursorConcurrency = 16
+
+// cursorSlots bounds how many requests may hold an open Mongo cursor at once.
+// It is separate from any general request limit: list and export endpoints
+// keep a cursor open for the whole read and are the expensive ones.
+var cursorSlots = make(chan struct{}, defaultCursorConcurrency)
+
+// InitCursorLimit reads EXPORT_CONCURRENCY, the number of cursor-holding
+// requests allowed to run concurrently
+func InitCursorLimit() {
+	raw := os.Getenv("EXPORT_CONCURRENCY")
+	if raw == "" {
+		return
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		log.Fatalf("Invalid EXPORT_CONCURRENCY %q: must be a positive number", raw)
+	}
+	cursorSlots = make(chan struct{}, n)
+}
+
+// acquireCursorSlot reserves a cursor slot without waiting. When none is free
+// it answers 503 and returns false; otherwise the caller must call the
+// returned release func.
+func acquireCursorSlot(w http.ResponseWriter) (release func(), ok bool) {
+	slots := cursorSlots
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+		w.Header().Set("Retry-After", "1")
+		utils.Error(w, "Too many concurrent list requests", http.StatusServiceUnavailable)
+		return nil, false
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useCursorLimit applies EXPORT_CONCURRENCY=n for one test
+func useCursorLimit(t *testing.T, n string) {
+	t.Helper()
+	saved := cursorSlots
+	t.Cleanup(func() { cursorSlots = saved })
+	t.Setenv("EXPORT_CONCURRENCY", n)
+	InitCursorLimit()
+}
+
+func TestCursorSlotsRunOut(t *testing.T) {
+	useCursorLimit(t, "2")
+	if cap(cursorSlots) != 2 {
+		t.Fatalf("%d slots, want 2", cap(cursorSlots))
+	}
+
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, ok := acquireCursorSlot(httptest.NewRecorder())
+		if !ok {
+			t.Fatalf("slot %d refused", i)
+		}
+		releases = append(releases, release)
+	}
+	rec := httptest.NewRecorder()
+	if _, ok := acquireCursorSlot(rec); ok || rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("third slot: ok=%v status %d, want 503 with Retry-After", ok, rec.Code)
+	}
+
+	releases[0]()
+	if release, ok := acquireCursorSlot(httptest.NewRecorder()); !ok {
+		t.Error("slot not reusable after release")
+	} else {
+		release()
+	}
+	releases[1]()
+}
+
+// stalledWriter blocks the first Write until released, keeping an export's
+// cursor open
+type stalledWriter struct {
+	*httptest.ResponseRecorder
+	once    sync.Once
+	release chan struct{}
+}
+
+func (s *stalledWriter) Write(b []byte) (int, error) {
+	s.once.Do(func() { <-s.release })
+	return s.ResponseRecorder.Write(b)
+}
+
+func TestExportConcurrencySaturated(t *testing.T) {
+	dbtest.Connect(t)
+	useCursorLimit(t, "2")
+	insertPost(t, models.Post{ID: 1, Body: "b", Version: 1})
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := &stalledWriter{ResponseRecorder: httptest.NewRecorder(), release: release}
+			ExportZipHandler(w, httptest.NewRequest(http.MethodGet, "/posts/export.zip", nil))
+		}()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(cursorSlots) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("exports never took their slots")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	rec := serve(ExportZipHandler, http.MethodGet, "/posts/export.zip", "")
+	close(release)
+	wg.Wait()
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("third export: status %d, want 503: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(ExportZipHandler, http.MethodGet, "/posts/export.zip", ""); rec.Code != http.StatusOK {
+		t.Errorf("export once the others finished: status %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"go-server/db/dbtest"
+	"go-server/middleware"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDBOpsHeader(t *testing.T) {
+	dbtest.Connect(t)
+	for id := 1; id <= 3; id++ {
+		insertPost(t, models.Post{ID: id, Body: "post", Version: 1})
+	}
+
+	ops := func(h http.HandlerFunc, method, target, body string) string {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		middleware.DBOps(h).ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s: status %d: %s", method, target, rec.Code, rec.Body)
+		}
+		return rec.Header().Get("X-DB-Ops")
+	}
+
+	// No Redis is running, so both reads go straight to MongoDB
+	if got := ops(PostHandler, http.MethodGet, "/posts/1", ""); got != "1" {
+		t.Errorf("single GET X-DB-Ops = %q, want 1", got)
+	}
+	// The batch fetches every miss with one $in query
+	if got := ops(BatchGetPostsHandler, http.MethodPost, "/posts/batch", `{"ids":[1,2,3,4]}`); got != "1" {
+		t.Errorf("batch X-DB-Ops = %q, want 1", got)
+	}
+}
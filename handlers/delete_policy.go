@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+// strictDelete restores the old DELETE contract: 200 with a message, and 404
+// when the post doesn't exist. By default DELETE is idempotent and answers
+// 204 either way, so clients can retry it safely.
+var strictDelete = false
+
+// InitDeletePolicy reads STRICT_DELETE
+func InitDeletePolicy() {
+	raw := os.Getenv("STRICT_DELETE")
+	if raw == "" {
+		return
+	}
+	strict, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Fatalf("Invalid STRICT_DELETE %q: must be true or false", raw)
+	}
+	strictDelete = strict
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"testing"
+)
+
+func useStrictDelete(t *testing.T, strict bool) {
+	t.Helper()
+	old := strictDelete
+	strictDelete = strict
+	t.Cleanup(func() { strictDelete = old })
+}
+
+func TestDeleteIsIdempotentByDefault(t *testing.T) {
+	dbtest.Connect(t)
+	useStrictDelete(t, false)
+	insertPost(t, models.Post{ID: 1, Body: "b", Version: 1})
+
+	// The first attempt deletes, the retry and a never-existing post succeed too
+	for _, target := range []string{"/posts/1", "/posts/1", "/posts/2"} {
+		rec := serve(PostHandler, http.MethodDelete, target, "")
+		if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+			t.Errorf("DELETE %s: status %d body %q, want an empty 204", target, rec.Code, rec.Body)
+		}
+	}
+	if rec := serve(PostHandler, http.MethodGet, "/posts/1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET after delete: status %d, want 404", rec.Code)
+	}
+}
+
+func TestStrictDeleteReportsMissingPost(t *testing.T) {
+	dbtest.Connect(t)
+	useStrictDelete(t, true)
+	insertPost(t, models.Post{ID: 1, Body: "b", Version: 1})
+
+	if rec := serve(PostHandler, http.MethodDelete, "/posts/1", ""); rec.Code != http.StatusOK {
+		t.Errorf("first delete: status %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(PostHandler, http.MethodDelete, "/posts/1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("retried delete: status %d, want 404: %s", rec.Code, rec.Body)
+	}
+}
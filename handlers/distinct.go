@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"go-server/cache"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const defaultMaxDistinctResults = 1000
+
+// maxDistinctResults caps how many values /posts/distinct returns
+var maxDistinctResults = defaultMaxDistinctResults
+
+// InitDistinctLimit reads MAX_DISTINCT_RESULTS
+func InitDistinctLimit() {
+	raw := os.Getenv("MAX_DISTINCT_RESULTS")
+	if raw == "" {
+		return
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		log.Fatalf("Invalid MAX_DISTINCT_RESULTS %q: must be a positive integer", raw)
+	}
+	maxDistinctResults = n
+}
+
+type DistinctResponse struct {
+	Field  string        `json:"field"`
+	Values []interface{} `json:"values"`
+	// Truncated is set when more values exist than MAX_DISTINCT_RESULTS
+	Truncated bool `json:"truncated"`
+}
+
+// Handling function for /posts/distinct endpoint
+// Lists the distinct values of a field registered as distinct, e.g. ?field=tags,
+// in ascending order and at most MAX_DISTINCT_RESULTS of them
+func DistinctValuesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	field, err := models.DistinctField(utils.QueryParam(r, "field"))
+	if err != nil {
+		utils.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Stored under the list prefix so any post write invalidates it
+	cacheKey := cache.BuildPostsListKey(fmt.Sprintf("distinct=%s&max=%d", field.Name, maxDistinctResults))
+	useCache := cache.EnabledFor(cache.RouteList)
+	if useCache {
+		var cached DistinctResponse
+		if _, found := cache.FetchFromCache(cacheKey, &cached); found {
+			utils.RespondWithJSON(w, cached)
+			return
+		}
+	}
+
+	if !requireDB(w) {
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	// Distinct returns every value at once, so group in an aggregation
+	// instead and stop one past the cap to learn whether there are more.
+	// $unwind flattens array fields and passes scalar ones through.
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: db.Active(bson.M{})}},
+		{{Key: "$unwind", Value: "$" + field.Name}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + field.Name}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$limit", Value: maxDistinctResults + 1}},
+	}
+	var groups []struct {
+		Value interface{} `bson:"_id"`
+	}
+	if err := db.Aggregate(ctx, pipeline, &groups); err != nil {
+		log.Printf("Error listing distinct %s: %v", field.Name, err)
+		utils.Error(w, "Error fetching distinct values", http.StatusInternalServerError)
+		return
+	}
+
+	truncated := len(groups) > maxDistinctResults
+	if truncated {
+		groups = groups[:maxDistinctResults]
+	}
+	values := make([]interface{}, len(groups))
+	for i, g := range groups {
+		values[i] = g.Value
+	}
+
+	resp := DistinctResponse{Field: field.JSONName, Values: values, Truncated: truncated}
+	if useCache {
+		cache.StoreInCache(cacheKey, resp)
+	}
+	utils.RespondWithJSON(w, resp)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/cache/cachetest"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"testing"
+)
+
+func distinctValues(t *testing.T, query string) DistinctResponse {
+	t.Helper()
+	rec := serve(DistinctValuesHandler, http.MethodGet, "/posts/distinct?"+query, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%q: status %d: %s", query, rec.Code, rec.Body)
+	}
+	var resp DistinctResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestDistinctTags(t *testing.T) {
+	dbtest.Connect(t)
+	deleted := models.Now()
+	for id, tags := range map[int][]string{1: {"go", "redis"}, 2: {"redis"}, 3: nil, 4: {"mongo", "go"}} {
+		insertPost(t, models.Post{ID: id, Body: "post", Tags: tags, Version: 1})
+	}
+	insertPost(t, models.Post{ID: 5, Body: "post", Tags: []string{"deleted-only"}, Version: 1, DeletedAt: &deleted})
+
+	resp := distinctValues(t, "field=tags")
+	want := []interface{}{"go", "mongo", "redis"}
+	if resp.Field != "tags" || resp.Truncated || len(resp.Values) != len(want) {
+		t.Fatalf("got %+v, want values %v", resp, want)
+	}
+	for i := range want {
+		if resp.Values[i] != want[i] {
+			t.Errorf("values %v, want %v", resp.Values, want)
+			break
+		}
+	}
+}
+
+func TestDistinctTruncatesAndCaches(t *testing.T) {
+	dbtest.Connect(t)
+	mr := cachetest.Start(t)
+	defer func(n int) { maxDistinctResults = n }(maxDistinctResults)
+	maxDistinctResults = 2
+	insertPost(t, models.Post{ID: 1, Body: "post", Tags: []string{"a", "b", "c"}, Version: 1})
+
+	resp := distinctValues(t, "field=tags")
+	if len(resp.Values) != 2 || !resp.Truncated {
+		t.Errorf("got %+v, want two values and truncated", resp)
+	}
+	if len(mr.Keys()) != 1 {
+		t.Errorf("cache keys %v, want the result cached once", mr.Keys())
+	}
+}
+
+func TestDistinctAtCapIsNotTruncated(t *testing.T) {
+	dbtest.Connect(t)
+	defer func(n int) { maxDistinctResults = n }(maxDistinctResults)
+	maxDistinctResults = 2
+	insertPost(t, models.Post{ID: 1, Body: "post", Tags: []string{"a", "b"}, Version: 1})
+
+	resp := distinctValues(t, "field=tags")
+	if len(resp.Values) != 2 || resp.Truncated {
+		t.Errorf("got %+v, want both values and not truncated", resp)
+	}
+}
+
+func TestDistinctRejectsDisallowedField(t *testing.T) {
+	for _, field := range []string{"body", "id", "password", ""} {
+		rec := serve(DistinctValuesHandler, http.MethodGet, "/posts/distinct?field="+field, "")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("field=%q: status %d, want 400", field, rec.Code)
+		}
+	}
+}
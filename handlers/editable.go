@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/models"
+	"go-server/utils"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// immutableFields can never be changed through an update. They are stored
+// on the post, so a client trying to set one gets 409 rather than the 400
+// for fields that don't exist at all.
+var immutableFields = map[string]bool{
+	"id":        true,
+	"objectId":  true,
+	"createdAt": true,
+	"updatedAt": true,
+	"version":   true,
+	"deletedAt": true,
+	"views":     true,
+}
+
+type FieldsErrorResponse struct {
+	utils.ErrorResponse
+	Fields   []string `json:"fields"`
+	Editable []string `json:"editable"`
+}
+
+// checkEditableFields only lets update bodies through when every top-level
+// key is an editable field, so nothing else can reach the $set. When it
+// returns false the error response has already been written.
+func checkEditableFields(w http.ResponseWriter, body []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		// Not an object; leave the error to schema validation
+		return true
+	}
+
+	editable := models.EditableFields()
+	var immutable, unknown []string
+	for name := range fields {
+		switch {
+		case immutableFields[name]:
+			immutable = append(immutable, name)
+		case !slices.Contains(editable, name):
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(immutable)
+	sort.Strings(unknown)
+
+	switch {
+	case len(immutable) > 0:
+		respondFieldsError(w, http.StatusConflict, utils.CodeImmutableField, "Fields cannot be changed: "+strings.Join(immutable, ", "), immutable, editable)
+		return false
+	case len(unknown) > 0:
+		respondFieldsError(w, http.StatusBadRequest, utils.CodeUnknownField, "Fields are not editable: "+strings.Join(unknown, ", "), unknown, editable)
+		return false
+	}
+	return true
+}
+
+func respondFieldsError(w http.ResponseWriter, status int, code utils.ErrorCode, message string, fields, editable []string) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	utils.RespondWithStatus(w, status, FieldsErrorResponse{
+		ErrorResponse: utils.ErrorResponse{Code: code, Message: message, Status: status},
+		Fields:        fields,
+		Editable:      editable,
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"go-server/utils"
+	"net/http"
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func storedPost(t *testing.T, id int) models.Post {
+	t.Helper()
+	var p models.Post
+	if err := db.FindOne(context.Background(), bson.M{"id": id}, &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestEditAllowsEditableFields(t *testing.T) {
+	dbtest.Connect(t)
+	insertPost(t, models.Post{ID: 1, Body: "before", Version: 1})
+
+	rec := serve(PostHandler, http.MethodPut, "/posts/1", `{"body":"after","tags":["go"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if p := storedPost(t, 1); p.Body != "after" || !slices.Equal(p.Tags, []string{"go"}) {
+		t.Errorf("stored %+v, want the update applied", p)
+	}
+}
+
+func TestEditRejectsNonEditableFields(t *testing.T) {
+	dbtest.Connect(t)
+	insertPost(t, models.Post{ID: 1, Body: "before", Version: 1})
+
+	tests := []struct {
+		name, payload string
+		status        int
+		code          utils.ErrorCode
+		fields        []string
+	}{
+		{"unknown", `{"body":"after","junk":1,"admin":true}`, http.StatusBadRequest, utils.CodeUnknownField, []string{"admin", "junk"}},
+		{"id", `{"body":"after","id":9999}`, http.StatusConflict, utils.CodeImmutableField, []string{"id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(PostHandler, http.MethodPut, "/posts/1", tt.payload)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			var resp FieldsErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code != tt.code || !slices.Equal(resp.Fields, tt.fields) || !slices.Contains(resp.Editable, "body") {
+				t.Errorf("response %+v, want code %s and fields %v", resp, tt.code, tt.fields)
+			}
+			if p := storedPost(t, 1); p.Body != "before" || p.ID != 1 {
+				t.Errorf("rejected update was applied: %+v", p)
+			}
+		})
+	}
+}
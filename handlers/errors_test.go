@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"go-server/utils"
+	"net/http"
+	"testing"
+)
+
+func errorCode(t *testing.T, body []byte) utils.ErrorCode {
+	t.Helper()
+	var resp struct {
+		Code utils.ErrorCode `json:"code"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatalf("decode %q: %v", body, err)
+	}
+	return resp.Code
+}
+
+func TestErrorCodes(t *testing.T) {
+	dbtest.Connect(t)
+	insertPost(t, models.Post{ID: 1, Body: "post", Version: 1})
+
+	tests := []struct {
+		name           string
+		h              http.HandlerFunc
+		method, target string
+		body           string
+		status         int
+		code           utils.ErrorCode
+	}{
+		{"missing post", PostHandler, http.MethodGet, "/posts/99", "", http.StatusNotFound, utils.CodePostNotFound},
+		{"invalid body", PostsHandler, http.MethodPost, "/posts", `{"body":""}`, http.StatusUnprocessableEntity, utils.CodeValidationFailed},
+		{"malformed json", PostsHandler, http.MethodPost, "/posts", `{"body":`, http.StatusBadRequest, utils.CodeInvalidRequest},
+		{"unknown field", PostHandler, http.MethodPut, "/posts/1", `{"nope":1}`, http.StatusBadRequest, utils.CodeUnknownField},
+		{"immutable field", PostHandler, http.MethodPut, "/posts/1", `{"id":2}`, http.StatusConflict, utils.CodeImmutableField},
+		{"empty update", PostHandler, http.MethodPut, "/posts/1", `{}`, http.StatusBadRequest, utils.CodeEmptyUpdate},
+		{"method", PostHandler, http.MethodPost, "/posts/1/raw", "", http.StatusMethodNotAllowed, utils.CodeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.h, tt.method, tt.target, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if got := errorCode(t, rec.Body.Bytes()); got != tt.code {
+				t.Errorf("code %q, want %q", got, tt.code)
+			}
+		})
+	}
+}
+
+func TestErrorCodeDBUnavailable(t *testing.T) {
+	// No dbtest.Connect: the server booted without MongoDB
+	rec := serve(PostHandler, http.MethodDelete, "/posts/1", "")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503: %s", rec.Code, rec.Body)
+	}
+	if got := errorCode(t, rec.Body.Bytes()); got != utils.CodeDBUnavailable {
+		t.Errorf("code %q, want %q", got, utils.CodeDBUnavailable)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Handling function for /posts/export.zip endpoint
+// Streams every live post as a ZIP archive with one file per post, either
+// {id}.json (default) or {id}.md with ?format=md. Posts are written as the
+// cursor yields them, so the archive is never held in memory.
+func ExportZipHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := utils.QueryParam(r, "format")
+	switch format {
+	case "":
+		format = "json"
+	case "json", "md":
+	default:
+		utils.Error(w, "format must be json or md", http.StatusBadRequest)
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+	release, ok := acquireCursorSlot(w)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// The request context, not dbTimeout: an export runs as long as it takes,
+	// and a client that hangs up cancels the cursor with it
+	ctx := r.Context()
+	cursor, err := db.PostCol.Find(ctx, db.Active(bson.M{}), options.Find().SetSort(bson.D{{Key: "id", Value: 1}}))
+	if err != nil {
+		log.Printf("Error starting export: %v", err)
+		utils.Error(w, "Error fetching posts", http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="posts.zip"`)
+	zw := zip.NewWriter(w)
+
+	// Once the first byte is out the status can't change, so failures past
+	// this point end the archive early; the missing central directory makes
+	// the download fail to open rather than look complete
+	count := 0
+	for cursor.Next(ctx) {
+		var p models.Post
+		if err := cursor.Decode(&p); err != nil {
+			log.Printf("Error decoding post during export: %v", err)
+			return
+		}
+		if err := writeExportFile(zw, p, format); err != nil {
+			log.Printf("Export stopped after %d posts: %v", count, err)
+			return
+		}
+		count++
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Export stopped after %d posts: %v", count, err)
+		return
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("Error finishing export: %v", err)
+	}
+}
+
+func writeExportFile(zw *zip.Writer, p models.Post, format string) error {
+	var content []byte
+	if format == "md" {
+		content = []byte(renderMarkdown(p))
+	} else {
+		var err error
+		if content, err = json.MarshalIndent(p, "", "  "); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     fmt.Sprintf("%d.%s", p.ID, format),
+		Method:   zip.Deflate,
+		Modified: p.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	return err
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// exportFiles unzips an export response into file name -> content
+func exportFiles(t *testing.T, query string) map[string]string {
+	t.Helper()
+	rec := serve(ExportZipHandler, http.MethodGet, "/posts/export.zip"+query, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="posts.zip"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+	files := make(map[string]string, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestExportZip(t *testing.T) {
+	dbtest.Connect(t)
+	deleted := models.Now()
+	insertPost(t, models.Post{ID: 1, Body: "first", Version: 1})
+	insertPost(t, models.Post{ID: 2, Body: "# second", Tags: []string{"go"}, Version: 1})
+	insertPost(t, models.Post{ID: 3, Body: "gone", Version: 1, DeletedAt: &deleted})
+
+	files := exportFiles(t, "")
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 1.json and 2.json", len(files))
+	}
+	for id, body := range map[string]string{"1.json": "first", "2.json": "# second"} {
+		var p models.Post
+		if err := json.Unmarshal([]byte(files[id]), &p); err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		if p.Body != body {
+			t.Errorf("%s has body %q, want %q", id, p.Body, body)
+		}
+	}
+
+	files = exportFiles(t, "?format=md")
+	if _, ok := files["2.md"]; !ok || !strings.HasSuffix(files["2.md"], "\n# second\n") {
+		t.Errorf("markdown export %v", files)
+	}
+}
+
+func TestExportZipRejectsUnknownFormat(t *testing.T) {
+	if rec := serve(ExportZipHandler, http.MethodGet, "/posts/export.zip?format=pdf", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultExtremesCount = 5
+	maxExtremesCount     = 50
+	extremesCacheTTL     = 30 * time.Second
+)
+
+// PostLength is a post with its body length in characters
+type PostLength struct {
+	models.Post `bson:",inline"`
+	BodyLength  int `json:"bodyLength" bson:"bodyLength"`
+}
+
+func (l PostLength) MarshalJSON() ([]byte, error) {
+	return models.MarshalWithFields(l.Post, struct {
+		BodyLength int `json:"bodyLength"`
+	}{l.BodyLength})
+}
+
+type ExtremesResponse struct {
+	Longest  []PostLength `json:"longest" bson:"longest"`
+	Shortest []PostLength `json:"shortest" bson:"shortest"`
+}
+
+// Computing lengths scans the collection, so results are kept briefly per count
+var extremesCache struct {
+	sync.Mutex
+	entries map[int]extremesEntry
+}
+
+type extremesEntry struct {
+	resp      ExtremesResponse
+	fetchedAt time.Time
+}
+
+// Handling function for /posts/extremes endpoint
+// Longest and shortest posts by body length, handy for spotting spam or empty posts
+func PostExtremesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	n := defaultExtremesCount
+	if raw := utils.QueryParam(r, "n"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxExtremesCount {
+			utils.Error(w, fmt.Sprintf("n must be between 1 and %d", maxExtremesCount), http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	resp, err := postExtremes(r.Context(), n)
+	if err != nil {
+		log.Printf("Error computing post extremes: %v", err)
+		utils.Error(w, "Error fetching posts", http.StatusInternalServerError)
+		return
+	}
+	utils.RespondWithJSON(w, resp)
+}
+
+func postExtremes(parent context.Context, n int) (ExtremesResponse, error) {
+	extremesCache.Lock()
+	defer extremesCache.Unlock()
+
+	if e, ok := extremesCache.entries[n]; ok && time.Since(e.fetchedAt) < extremesCacheTTL {
+		return e.resp, nil
+	}
+
+	ctx, cancel := dbContext(parent)
+	defer cancel()
+
+	bySize := func(direction int) bson.A {
+		return bson.A{
+			bson.M{"$sort": bson.D{{Key: "bodyLength", Value: direction}, {Key: "id", Value: 1}}},
+			bson.M{"$limit": n},
+		}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: db.Active(bson.M{})}},
+		{{Key: "$addFields", Value: bson.M{"bodyLength": bson.M{"$strLenCP": bson.M{"$ifNull": bson.A{"$body", ""}}}}}},
+		{{Key: "$facet", Value: bson.M{"longest": bySize(-1), "shortest": bySize(1)}}},
+	}
+
+	var out []ExtremesResponse
+	if err := db.Aggregate(ctx, pipeline, &out); err != nil {
+		return ExtremesResponse{}, err
+	}
+
+	resp := ExtremesResponse{Longest: []PostLength{}, Shortest: []PostLength{}}
+	if len(out) > 0 {
+		if out[0].Longest != nil {
+			resp.Longest = out[0].Longest
+		}
+		if out[0].Shortest != nil {
+			resp.Shortest = out[0].Shortest
+		}
+	}
+
+	if extremesCache.entries == nil {
+		extremesCache.entries = make(map[int]extremesEntry)
+	}
+	extremesCache.entries[n] = extremesEntry{resp: resp, fetchedAt: time.Now()}
+	return resp, nil
+}
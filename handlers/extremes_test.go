@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestExtremesOrderByLength(t *testing.T) {
+	dbtest.Connect(t)
+	extremesCache.Lock()
+	extremesCache.entries = nil
+	extremesCache.Unlock()
+
+	deleted := models.Now()
+	for _, p := range []models.Post{
+		{ID: 1, Body: "medium body"},
+		{ID: 2, Body: "x"},
+		{ID: 3, Body: "the longest body of them all"},
+		{ID: 4, Body: "ééé"}, // three characters, six bytes
+		{ID: 5, Body: "xy"},
+		{ID: 6, Body: "deleted, and longer than every live post", DeletedAt: &deleted},
+	} {
+		p.Version = 1
+		insertPost(t, p)
+	}
+
+	rec := serve(PostExtremesHandler, http.MethodGet, "/posts/extremes?n=3", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp ExtremesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(posts []PostLength) []int {
+		out := make([]int, len(posts))
+		for i, p := range posts {
+			out[i] = p.ID
+			if p.BodyLength != len([]rune(p.Body)) {
+				t.Errorf("post %d bodyLength %d, want %d characters", p.ID, p.BodyLength, len([]rune(p.Body)))
+			}
+		}
+		return out
+	}
+	if got := ids(resp.Longest); !slices.Equal(got, []int{3, 1, 4}) {
+		t.Errorf("longest %v, want [3 1 4]", got)
+	}
+	if got := ids(resp.Shortest); !slices.Equal(got, []int{2, 5, 4}) {
+		t.Errorf("shortest %v, want [2 5 4]", got)
+	}
+}
+
+func TestExtremesValidatesCount(t *testing.T) {
+	for _, n := range []string{"0", "51", "many"} {
+		if rec := serve(PostExtremesHandler, http.MethodGet, "/posts/extremes?n="+n, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("n=%s: status %d, want 400", n, rec.Code)
+		}
+	}
+}
+
+func TestPostLengthJSONKeepsBodyLength(t *testing.T) {
+	data, err := json.Marshal(ExtremesResponse{Longest: []PostLength{{Post: models.Post{ID: 1, Body: "four"}, BodyLength: 4}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Longest []map[string]interface{} `json:"longest"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Longest) != 1 || got.Longest[0]["bodyLength"] != 4.0 || got.Longest[0]["body"] != "four" {
+		t.Errorf("got %s, want the post fields and bodyLength", data)
+	}
+}
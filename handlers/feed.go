@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	feedSize       = 20
+	feedCacheTTL   = 30 * time.Second
+	feedTitleRunes = 80
+)
+
+// The feed is cheap to render but hit often by polling readers, so the
+// latest posts are held in memory for a short while
+var feedCache struct {
+	sync.Mutex
+	posts     []models.Post
+	fetchedAt time.Time
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Description string `xml:"description"`
+}
+
+// Handling function for /posts/feed.xml endpoint
+// RSS 2.0 feed of the latest posts
+func PostsFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	posts, err := latestPosts(r.Context())
+	if err != nil {
+		respondListError(w, err)
+		return
+	}
+
+	base := requestBaseURL(r)
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       "Posts",
+			Link:        base + "/posts",
+			Description: "Latest posts",
+			Items:       make([]rssItem, len(posts)),
+		},
+	}
+	for i, p := range posts {
+		link := fmt.Sprintf("%s/posts/%d", base, p.ID)
+		feed.Channel.Items[i] = rssItem{
+			Title:       feedTitle(p.Body),
+			Link:        link,
+			GUID:        link,
+			PubDate:     p.CreatedAt.UTC().Format(time.RFC1123Z),
+			Description: p.Body,
+		}
+	}
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		utils.Error(w, "Error rendering feed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(feedCacheTTL.Seconds())))
+	w.Write([]byte(xml.Header))
+	w.Write(out)
+}
+
+// latestPosts returns the newest feedSize posts, served from memory for up
+// to feedCacheTTL
+func latestPosts(parent context.Context) ([]models.Post, error) {
+	feedCache.Lock()
+	defer feedCache.Unlock()
+
+	if feedCache.posts != nil && time.Since(feedCache.fetchedAt) < feedCacheTTL {
+		return feedCache.posts, nil
+	}
+
+	ctx, cancel := dbContext(parent)
+	defer cancel()
+
+	posts, err := db.FindPosts(ctx, db.Active(bson.M{}), options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}}).
+		SetLimit(feedSize))
+	if err != nil {
+		return nil, err
+	}
+	if posts == nil {
+		posts = []models.Post{}
+	}
+	feedCache.posts = posts
+	feedCache.fetchedAt = time.Now()
+	return posts, nil
+}
+
+// feedTitle uses the first line of the body, cut to feedTitleRunes
+func feedTitle(body string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) <= feedTitleRunes {
+		return title
+	}
+	runes := []rune(title)
+	return strings.TrimSpace(string(runes[:feedTitleRunes-1])) + "…"
+}
+
+// requestBaseURL rebuilds the scheme and host the client used to reach us
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetFeedCache(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		feedCache.Lock()
+		feedCache.posts = nil
+		feedCache.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestPostsFeed(t *testing.T) {
+	dbtest.Connect(t)
+	resetFeedCache(t)
+	older := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	insertPost(t, models.Post{ID: 1, Body: "First post\nwith more lines", Version: 1, CreatedAt: older, UpdatedAt: older})
+	insertPost(t, models.Post{ID: 2, Body: "Second & <newer>", Version: 1, CreatedAt: older.Add(time.Hour), UpdatedAt: older.Add(time.Hour)})
+
+	rec := serve(PostsFeedHandler, http.MethodGet, "/posts/feed.xml", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/rss+xml") {
+		t.Errorf("Content-Type = %q, want application/rss+xml", ct)
+	}
+
+	var feed rssFeed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid feed XML: %v\n%s", err, rec.Body)
+	}
+	if feed.Version != "2.0" {
+		t.Errorf("rss version %q, want 2.0", feed.Version)
+	}
+	want := []rssItem{
+		{Title: "Second & <newer>", Link: "http://example.com/posts/2", PubDate: "Fri, 01 Mar 2024 10:00:00 +0000"},
+		{Title: "First post", Link: "http://example.com/posts/1", PubDate: "Fri, 01 Mar 2024 09:00:00 +0000"},
+	}
+	if len(feed.Channel.Items) != len(want) {
+		t.Fatalf("%d items, want %d", len(feed.Channel.Items), len(want))
+	}
+	for i, item := range feed.Channel.Items {
+		if item.Title != want[i].Title || item.Link != want[i].Link || item.GUID != want[i].Link || item.PubDate != want[i].PubDate {
+			t.Errorf("item %d = %+v, want %+v", i, item, want[i])
+		}
+	}
+}
+
+func TestFeedTitle(t *testing.T) {
+	long := strings.Repeat("é", feedTitleRunes+5)
+	tests := map[string]string{
+		"  one line  ":       "one line",
+		"headline\nthe rest": "headline",
+		long:                 strings.Repeat("é", feedTitleRunes-1) + "…",
+	}
+	for body, want := range tests {
+		if got := feedTitle(body); got != want {
+			t.Errorf("feedTitle(%q) = %q, want %q", body, got, want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go-server/db"
+	"go-server/events"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Handling function for /posts/findOrCreate endpoint
+// Returns the existing post with the same (normalized) body with 200, or
+// creates it and returns 201. X-Find-Or-Create says which happened.
+func FindOrCreatePostHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	p, ok := decodeNewPost(w, r)
+	if !ok {
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	// Most calls find an existing post; look first so they don't use up an id
+	var stored models.Post
+	err := db.FindOne(ctx, db.Active(bson.M{"body": p.Body}), &stored)
+	if err == nil {
+		respondFound(w, stored)
+		return
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		log.Printf("Error in findOrCreate: %v", err)
+		utils.Error(w, "Error creating post", http.StatusInternalServerError)
+		return
+	}
+
+	id, err := nextPostID(ctx)
+	if err != nil {
+		log.Printf("Error getting max ID: %v", err)
+		utils.Error(w, "Error creating post", http.StatusInternalServerError)
+		return
+	}
+
+	stored, err = upsertByBody(ctx, p, id)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent call (possibly on another instance) inserted the same
+		// body between our upsert's lookup and its insert. Its post is
+		// there now, unless the claim was left behind by a post that has
+		// since been edited or deleted; release that and try once more.
+		if err = releaseStaleClaim(ctx, p.Body); err == nil {
+			stored, err = upsertByBody(ctx, p, id)
+		}
+	}
+	if err != nil {
+		log.Printf("Error in findOrCreate: %v", err)
+		utils.Error(w, "Error creating post", http.StatusInternalServerError)
+		return
+	}
+
+	if stored.ID != id {
+		respondFound(w, stored)
+		return
+	}
+
+	events.Publish(events.Event{Type: events.PostCreated, PostID: stored.ID, Post: stored})
+	w.Header().Set("X-Find-Or-Create", "created")
+	utils.RespondWithStatus(w, http.StatusCreated, stored)
+}
+
+func respondFound(w http.ResponseWriter, p models.Post) {
+	w.Header().Set("X-Find-Or-Create", "found")
+	utils.RespondWithJSON(w, p)
+}
+
+// upsertByBody returns the live post with p's body, inserting p under id if
+// there is none. On its own an upsert can still insert twice when two calls
+// race, so inserted posts also claim their body in the unique body_hash
+// index; the loser of a race gets a duplicate key error.
+func upsertByBody(ctx context.Context, p models.Post, id int) (models.Post, error) {
+	now := models.Now()
+	// body comes from the filter; only the remaining fields are set on insert
+	onInsert := bson.M{
+		"id":         id,
+		"body_hash":  bodyHash(p.Body),
+		"created_at": now,
+		"updated_at": now,
+		"version":    1,
+	}
+	if len(p.Tags) > 0 {
+		onInsert["tags"] = p.Tags
+	}
+	update := bson.M{"$setOnInsert": onInsert}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var stored models.Post
+	err := db.PostCol.FindOneAndUpdate(ctx, db.Active(bson.M{"body": p.Body}), update, opts).Decode(&stored)
+	return stored, err
+}
+
+// releaseStaleClaim drops the body_hash claim on body from a post that no
+// longer holds that body live. Edits and deletes don't clear claims
+// themselves; a claim only matters when findOrCreate trips over it.
+func releaseStaleClaim(ctx context.Context, body string) error {
+	filter := bson.M{
+		"body_hash": bodyHash(body),
+		"$or": bson.A{
+			bson.M{"body": bson.M{"$ne": body}},
+			bson.M{"deleted_at": bson.M{"$exists": true}},
+		},
+	}
+	_, err := db.PostCol.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"body_hash": ""}})
+	return err
+}
+
+// bodyHash keys the uniqueness claim; a hash keeps index entries small no
+// matter how long bodies get
+func bodyHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
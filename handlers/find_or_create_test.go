@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func findOrCreate(t *testing.T, body string) (*httptest.ResponseRecorder, models.Post) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/posts/findOrCreate", strings.NewReader(`{"body":`+body+`}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	FindOrCreatePostHandler(rec, req)
+
+	var p models.Post
+	if rec.Code == http.StatusOK || rec.Code == http.StatusCreated {
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Errorf("decoding response: %v", err)
+		}
+	}
+	return rec, p
+}
+
+func TestFindOrCreateConcurrentCreatesOnce(t *testing.T) {
+	dbtest.Connect(t)
+
+	const callers = 20
+	var wg sync.WaitGroup
+	recs := make([]*httptest.ResponseRecorder, callers)
+	posts := make([]models.Post, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recs[i], posts[i] = findOrCreate(t, `"same body"`)
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for i, rec := range recs {
+		switch rec.Code {
+		case http.StatusCreated:
+			created++
+		case http.StatusOK:
+		default:
+			t.Fatalf("call %d: status %d: %s", i, rec.Code, rec.Body)
+		}
+		if posts[i].ID != posts[0].ID {
+			t.Errorf("call %d got post %d, call 0 got %d", i, posts[i].ID, posts[0].ID)
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d calls created the post, want 1", created)
+	}
+
+	n, err := db.PostCol.CountDocuments(context.Background(), bson.M{"body": "same body"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("%d posts stored with the body, want 1", n)
+	}
+}
+
+func TestFindOrCreateFoundDoesNotUseID(t *testing.T) {
+	dbtest.Connect(t)
+
+	_, first := findOrCreate(t, `"first"`)
+	for i := 0; i < 3; i++ {
+		if rec, _ := findOrCreate(t, `"first"`); rec.Header().Get("X-Find-Or-Create") != "found" {
+			t.Fatalf("repeat call: X-Find-Or-Create = %q, want found", rec.Header().Get("X-Find-Or-Create"))
+		}
+	}
+	_, second := findOrCreate(t, `"second"`)
+	if second.ID != first.ID+1 {
+		t.Errorf("second post got id %d, want %d", second.ID, first.ID+1)
+	}
+}
+
+func TestFindOrCreateAfterClaimHolderEdited(t *testing.T) {
+	dbtest.Connect(t)
+
+	_, original := findOrCreate(t, `"draft"`)
+	if _, err := db.PostCol.UpdateOne(context.Background(), bson.M{"id": original.ID}, bson.M{"$set": bson.M{"body": "final"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The edited post still holds the claim on "draft"
+	rec, p := findOrCreate(t, `"draft"`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d, want 201: %s", rec.Code, rec.Body)
+	}
+	if p.ID == original.ID {
+		t.Errorf("got the edited post %d back", p.ID)
+	}
+}
+
+func TestFindOrCreateMatchesNormalizedBody(t *testing.T) {
+	dbtest.Connect(t)
+
+	_, created := findOrCreate(t, `"  café au lait "`)
+	if created.Body != "café au lait" {
+		t.Fatalf("stored body %q, want it trimmed", created.Body)
+	}
+	// Decomposed accent and different padding: the same body once normalized
+	rec, found := findOrCreate(t, `"cafe\u0301 au lait\n"`)
+	if rec.Header().Get("X-Find-Or-Create") != "found" || found.ID != created.ID {
+		t.Errorf("got post %d (%s), want the existing post %d found", found.ID, rec.Header().Get("X-Find-Or-Create"), created.ID)
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"go-server/cache"
+	"go-server/db"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+const defaultHealthCheckTimeout = 2 * time.Second
+
+var healthCheckTimeout = defaultHealthCheckTimeout
+
+type DependencyHealth struct {
+	OK        bool   `json:"ok"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ReadinessResponse struct {
+	Status string           `json:"status"`
+	Mongo  DependencyHealth `json:"mongo"`
+	Redis  DependencyHealth `json:"redis"`
+}
+
+// InitHealthChecks reads HEALTH_CHECK_TIMEOUT, the longest a readiness probe
+// waits on any one dependency
+func InitHealthChecks() {
+	raw := os.Getenv("HEALTH_CHECK_TIMEOUT")
+	if raw == "" {
+		return
+	}
+	timeout, err := time.ParseDuration(raw)
+	if err != nil || timeout <= 0 {
+		log.Fatalf("Invalid HEALTH_CHECK_TIMEOUT %q: must be a positive duration", raw)
+	}
+	healthCheckTimeout = timeout
+}
+
+// Handling function for /healthz endpoint
+// Liveness only: the process is up and serving
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	utils.RespondWithJSON(w, map[string]string{"status": "ok"})
+}
+
+// Handling function for /readyz endpoint
+// Pings MongoDB and Redis and reports each one's latency. MongoDB is required,
+// so its failure returns 503; a Redis failure only degrades caching.
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	resp := ReadinessResponse{
+		Status: "ok",
+		Mongo:  checkDependency(r.Context(), db.Ping),
+		Redis:  checkDependency(r.Context(), cache.Ping),
+	}
+
+	status := http.StatusOK
+	switch {
+	case !resp.Mongo.OK:
+		resp.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+	case !resp.Redis.OK:
+		resp.Status = "degraded"
+	}
+	utils.RespondWithStatus(w, status, resp)
+}
+
+func checkDependency(parent context.Context, ping func(context.Context) error) DependencyHealth {
+	ctx, cancel := context.WithTimeout(parent, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(ctx)
+	health := DependencyHealth{OK: err == nil, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		health.Error = err.Error()
+	}
+	return health
+}
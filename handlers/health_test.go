@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-server/cache/cachetest"
+	"go-server/db/dbtest"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// readyz returns the status and the raw JSON body, so the test sees the
+// latency fields as the dashboards do rather than through the Go struct
+func readyz(t *testing.T) (int, map[string]map[string]interface{}) {
+	t.Helper()
+	rec := serve(ReadyzHandler, http.MethodGet, "/readyz", "")
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %s: %v", rec.Body, err)
+	}
+	deps := map[string]map[string]interface{}{}
+	for _, name := range []string{"mongo", "redis"} {
+		var dep map[string]interface{}
+		if err := json.Unmarshal(body[name], &dep); err != nil {
+			t.Fatalf("decoding %s: %v", name, err)
+		}
+		deps[name] = dep
+	}
+	return rec.Code, deps
+}
+
+func assertLatency(t *testing.T, deps map[string]map[string]interface{}) {
+	t.Helper()
+	for name, dep := range deps {
+		latency, ok := dep["latencyMs"].(float64)
+		if !ok {
+			t.Errorf("%s latencyMs = %#v, want a number", name, dep["latencyMs"])
+		} else if latency < 0 {
+			t.Errorf("%s latencyMs = %v, want >= 0", name, latency)
+		}
+	}
+}
+
+func TestReadyzReportsLatency(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+
+	status, deps := readyz(t)
+	if status != http.StatusOK {
+		t.Errorf("status %d, want 200", status)
+	}
+	for name, dep := range deps {
+		if dep["ok"] != true {
+			t.Errorf("%s ok = %v, want true", name, dep["ok"])
+		}
+	}
+	assertLatency(t, deps)
+}
+
+func TestReadyzReportsLatencyWhenMongoIsDown(t *testing.T) {
+	cachetest.Start(t)
+
+	status, deps := readyz(t)
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("status %d, want 503", status)
+	}
+	if deps["mongo"]["ok"] != false || deps["mongo"]["error"] == nil {
+		t.Errorf("mongo = %v, want a failure with an error", deps["mongo"])
+	}
+	assertLatency(t, deps)
+}
+
+func TestCheckDependencyTimesOut(t *testing.T) {
+	defer func(d time.Duration) { healthCheckTimeout = d }(healthCheckTimeout)
+	healthCheckTimeout = 20 * time.Millisecond
+
+	health := checkDependency(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if health.OK {
+		t.Fatal("hung dependency reported healthy")
+	}
+	if health.LatencyMs < 20 || health.LatencyMs > 1000 {
+		t.Errorf("latency %dms, want about the 20ms timeout", health.LatencyMs)
+	}
+}
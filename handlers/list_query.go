@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	tagModeAny = "any"
+	tagModeAll = "all"
+)
+
+var errUnknownAnchor = errors.New("after refers to a post that does not exist")
+
+// listQuery is everything a client controls on GET /posts.
+//
+// Parameters combine as: filters (tags, contains) narrow the set, sort orders
+// it with id as tie-breaker, then either the after cursor or offset picks the
+// page and fields trims each post. When after is given, offset is ignored.
+type listQuery struct {
+	Limit         int
+	Offset        int
+	SortField     string
+	SortDirection int
+	Tags          []string
+	TagMode       string
+	Contains      string
+	IDFrom        int
+	IDTo          int
+	After         int
+	Fields        []models.FieldInfo
+
+	// OffsetIgnored is set when after overrode a non-zero offset
+	OffsetIgnored bool
+
+	// afterValue is the anchor post's value for SortField, set by resolveAfter
+	afterValue interface{}
+}
+
+func parseListQuery(r *http.Request) (listQuery, error) {
+	var q listQuery
+	var err error
+
+	q.Limit, q.Offset = utils.ParsePaginationParams(r)
+	if q.SortField, q.SortDirection, err = utils.ParseSortParams(r); err != nil {
+		return q, err
+	}
+
+	// ?tag=x is shorthand for a single-entry ?tags=
+	rawTags := utils.QueryParam(r, "tags")
+	if tag := utils.QueryParam(r, "tag"); tag != "" {
+		rawTags = strings.Trim(rawTags+","+tag, ",")
+	}
+	if rawTags != "" {
+		if _, err := models.FilterableField("tags"); err != nil {
+			return q, err
+		}
+		for _, tag := range strings.Split(rawTags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				q.Tags = append(q.Tags, tag)
+			}
+		}
+		sort.Strings(q.Tags)
+	}
+
+	if raw := utils.QueryParam(r, "contains"); raw != "" {
+		if _, err := models.FilterableField("body"); err != nil {
+			return q, err
+		}
+		q.Contains = strings.TrimSpace(raw)
+	}
+
+	if q.IDFrom, err = parseIDBound(r, "idFrom"); err != nil {
+		return q, err
+	}
+	if q.IDTo, err = parseIDBound(r, "idTo"); err != nil {
+		return q, err
+	}
+	if q.IDFrom > 0 && q.IDTo > 0 && q.IDFrom > q.IDTo {
+		return q, fmt.Errorf("idFrom (%d) must not be greater than idTo (%d)", q.IDFrom, q.IDTo)
+	}
+
+	if raw := utils.QueryParam(r, "after"); raw != "" {
+		if q.After, err = strconv.Atoi(raw); err != nil || q.After < 1 {
+			return q, fmt.Errorf("after must be a positive post id, got %q", raw)
+		}
+		// Keyset paging replaces offset paging
+		if q.Offset > 0 {
+			q.OffsetIgnored = true
+			q.Offset = 0
+		}
+	}
+
+	q.TagMode = utils.QueryParam(r, "tagMode")
+	switch q.TagMode {
+	case "":
+		q.TagMode = tagModeAny
+	case tagModeAny, tagModeAll:
+	default:
+		return q, fmt.Errorf("tagMode must be %q or %q, got %q", tagModeAny, tagModeAll, q.TagMode)
+	}
+
+	if raw := utils.QueryParam(r, "fields"); raw != "" {
+		seen := make(map[string]bool)
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || seen[name] {
+				continue
+			}
+			info, err := models.SelectableField(name)
+			if err != nil {
+				return q, err
+			}
+			seen[name] = true
+			q.Fields = append(q.Fields, info)
+		}
+		sort.Slice(q.Fields, func(i, j int) bool { return q.Fields[i].Name < q.Fields[j].Name })
+	}
+	return q, nil
+}
+
+// parseIDBound reads one end of the ?idFrom=&idTo= range; 0 means open
+func parseIDBound(r *http.Request, name string) (int, error) {
+	raw := utils.QueryParam(r, name)
+	if raw == "" {
+		return 0, nil
+	}
+	if _, err := models.FilterableField("id"); err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive post id, got %q", name, raw)
+	}
+	return n, nil
+}
+
+// filter builds the Mongo filter for the query, excluding deleted posts
+func (q listQuery) filter() bson.M {
+	filter := bson.M{}
+	if q.IDFrom > 0 || q.IDTo > 0 {
+		idRange := bson.M{}
+		if q.IDFrom > 0 {
+			idRange["$gte"] = q.IDFrom
+		}
+		if q.IDTo > 0 {
+			idRange["$lte"] = q.IDTo
+		}
+		filter["id"] = idRange
+	}
+	if len(q.Tags) > 0 {
+		op := "$in"
+		if q.TagMode == tagModeAll {
+			op = "$all"
+		}
+		filter[models.PostFields["tags"].Name] = bson.M{op: q.Tags}
+	}
+	if q.Contains != "" {
+		filter[models.PostFields["body"].Name] = bson.M{"$regex": regexp.QuoteMeta(q.Contains), "$options": "i"}
+	}
+	return filter
+}
+
+// pageFilter is filter narrowed to the posts after the keyset anchor. Counts
+// use filter so totals don't shrink as a client pages through.
+func (q listQuery) pageFilter() bson.M {
+	filter := q.filter()
+	if q.After == 0 {
+		return filter
+	}
+
+	op := "$gt"
+	if q.SortDirection < 0 {
+		op = "$lt"
+	}
+	if q.SortField == "id" {
+		idCond, ok := filter["id"].(bson.M)
+		if !ok {
+			idCond = bson.M{}
+		}
+		// Joins any idFrom/idTo bounds, which use $gte/$lte
+		idCond[op] = q.After
+		filter["id"] = idCond
+		return filter
+	}
+	// Posts sharing the anchor's sort value are ordered by id ascending
+	filter["$or"] = bson.A{
+		bson.M{q.SortField: bson.M{op: q.afterValue}},
+		bson.M{q.SortField: q.afterValue, "id": bson.M{"$gt": q.After}},
+	}
+	return filter
+}
+
+// resolveAfter looks up the anchor post's sort value. Deleted posts still
+// work as anchors so a cursor survives its post being removed.
+func (q *listQuery) resolveAfter(ctx context.Context) error {
+	if q.After == 0 || q.SortField == "id" {
+		return nil
+	}
+	var anchor bson.M
+	if err := db.FindOne(ctx, bson.M{"id": q.After}, &anchor); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errUnknownAnchor
+		}
+		return err
+	}
+	q.afterValue = anchor[q.SortField]
+	return nil
+}
+
+func (q listQuery) findOptions() *options.FindOptions {
+	sort := bson.D{{Key: q.SortField, Value: q.SortDirection}}
+	if q.SortField != "id" {
+		// Break ties so pages stay stable
+		sort = append(sort, bson.E{Key: "id", Value: 1})
+	}
+	opts := options.Find().SetLimit(int64(q.Limit)).SetSkip(int64(q.Offset)).SetSort(sort)
+	if len(q.Fields) > 0 {
+		projection := bson.M{}
+		for _, f := range q.Fields {
+			projection[f.Name] = 1
+		}
+		opts.SetProjection(projection)
+	}
+	return opts
+}
+
+// shape returns posts as the client asked for them: whole, or trimmed to the
+// ?fields= selection
+func (q listQuery) shape(posts []models.Post) interface{} {
+	if len(q.Fields) == 0 {
+		return posts
+	}
+	selected := make([]map[string]interface{}, len(posts))
+	for i, p := range posts {
+		selected[i] = models.SelectFields(p, q.Fields)
+	}
+	return selected
+}
+
+// cacheKey renders the query canonically, so equivalent requests share one
+// cache entry and different pages or filters never collide
+func (q listQuery) cacheKey() string {
+	fields := make([]string, len(q.Fields))
+	for i, f := range q.Fields {
+		fields[i] = f.Name
+	}
+	return fmt.Sprintf("sort=%s:%d:limit=%d:offset=%d:after=%d:ids=%d-%d:tags=%s:tagMode=%s:contains=%s:fields=%s",
+		q.SortField, q.SortDirection, q.Limit, q.Offset, q.After, q.IDFrom, q.IDTo, strings.Join(q.Tags, ","), q.TagMode,
+		url.QueryEscape(q.Contains), strings.Join(fields, ","))
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/cache/cachetest"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listIDs runs GET /posts?query and returns the ids on the page and the total
+func listIDs(t *testing.T, query string) ([]int, int64) {
+	t.Helper()
+	rec := serve(PostsHandler, http.MethodGet, "/posts?"+query, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%q: status %d: %s", query, rec.Code, rec.Body)
+	}
+	var page struct {
+		Posts      []models.Post `json:"posts"`
+		TotalPosts int64         `json:"totalPosts"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int, len(page.Posts))
+	for i, p := range page.Posts {
+		ids[i] = p.ID
+	}
+	return ids, page.TotalPosts
+}
+
+func TestTagModes(t *testing.T) {
+	dbtest.Connect(t)
+	for id, tags := range map[int][]string{1: {"a"}, 2: {"b"}, 3: {"a", "b"}, 4: {"c"}, 5: nil, 6: {"a", "b", "c"}} {
+		insertPost(t, models.Post{ID: id, Body: "post", Tags: tags, Version: 1})
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"tags=a,b", []int{1, 2, 3, 6}},
+		{"tags=a,b&tagMode=any", []int{1, 2, 3, 6}},
+		{"tags=a,b&tagMode=all", []int{3, 6}},
+		{"tags=b,c&tagMode=all", []int{6}},
+		{"tag=c", []int{4, 6}},
+		{"tags=a&tag=c&tagMode=all", []int{6}},
+		{"tags=z&tagMode=all", []int{}},
+	}
+	for _, tt := range tests {
+		ids, total := listIDs(t, tt.query)
+		if !slices.Equal(ids, tt.want) || total != int64(len(tt.want)) {
+			t.Errorf("%q: got %v (total %d), want %v", tt.query, ids, total, tt.want)
+		}
+	}
+}
+
+func TestTagModeValidatedAndKeyed(t *testing.T) {
+	if rec := serve(PostsHandler, http.MethodGet, "/posts?tags=a&tagMode=some", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown tagMode: status %d, want 400", rec.Code)
+	}
+
+	key := func(query string) string {
+		q, err := parseListQuery(httptest.NewRequest(http.MethodGet, "/posts?"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return q.cacheKey()
+	}
+	if key("tags=a,b&tagMode=any") == key("tags=a,b&tagMode=all") {
+		t.Error("any and all share a cache key")
+	}
+	if key("tags=a,b") != key("tags=b,a&tagMode=any") {
+		t.Error("the same tag set in another order gets its own cache key")
+	}
+}
+
+func TestListQueryRejectsUnregisteredFields(t *testing.T) {
+	for _, query := range []string{"sort=deleted_at", "sort=body", "fields=id,deletedAt", "fields=secret"} {
+		req := httptest.NewRequest(http.MethodGet, "/posts?"+query, nil)
+		if _, err := parseListQuery(req); err == nil {
+			t.Errorf("?%s accepted", query)
+		}
+	}
+	for _, field := range []string{"deleted_at", "body"} {
+		rec := serve(DistinctValuesHandler, http.MethodGet, "/posts/distinct?field="+field, "")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("distinct %s: status %d, want 400", field, rec.Code)
+		}
+	}
+}
+
+func TestListQueryCombinesEveryFeature(t *testing.T) {
+	dbtest.Connect(t)
+	mr := cachetest.Start(t)
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, p := range []struct {
+		id    int
+		tag   string
+		body  string
+		hours int
+	}{
+		{1, "go", "redis one", 0},
+		{2, "go", "about REDIS", 2},
+		{3, "go", "redis", 2}, // ties with 2 on created_at
+		{4, "go", "redis", 3},
+		{5, "go", "postgres", 4},
+		{6, "rust", "redis", 5},
+		{7, "go", "redis", 1},
+	} {
+		at := t0.Add(time.Duration(p.hours) * time.Hour)
+		insertPost(t, models.Post{ID: p.id, Body: p.body, Tags: []string{p.tag}, Version: 1, CreatedAt: at, UpdatedAt: at})
+	}
+
+	// Newest first, ties by id, walked with the keyset cursor
+	base := "tag=go&contains=redis&sort=created_at&order=desc&fields=id,body&limit=2"
+	for _, page := range []struct {
+		after string
+		want  []int
+	}{
+		{"", []int{4, 2}},
+		{"&after=2", []int{3, 7}},
+		{"&after=7", []int{1}},
+	} {
+		ids, total := listIDs(t, base+page.after)
+		if !slices.Equal(ids, page.want) || total != 5 {
+			t.Errorf("after %q: got %v (total %d), want %v of 5", page.after, ids, total, page.want)
+		}
+	}
+
+	// Only the selected fields come back
+	rec := serve(PostsHandler, http.MethodGet, "/posts?"+base, "")
+	var page struct {
+		Posts []map[string]interface{} `json:"posts"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range page.Posts {
+		if len(p) != 2 || p["id"] == nil || p["body"] == nil {
+			t.Errorf("post %v, want only id and body", p)
+		}
+	}
+
+	// One cache entry per distinct query, however its parameters are written
+	keys := len(mr.Keys())
+	if keys != 3 {
+		t.Fatalf("%d list pages cached, want 3", keys)
+	}
+	rec = serve(PostsHandler, http.MethodGet, "/posts?limit=2&fields=body,id&order=desc&sort=createdAt&tags=go&contains=redis&after=2", "")
+	if rec.Header().Get("X-Cache-Age") == "" {
+		t.Errorf("reordered query missed the cache: %s", rec.Body)
+	}
+	if n := len(mr.Keys()); n != keys {
+		t.Errorf("reordered query added a cache entry: %d keys, want %d", n, keys)
+	}
+}
+
+func TestIDRanges(t *testing.T) {
+	dbtest.Connect(t)
+	for id := 1; id <= 8; id++ {
+		insertPost(t, models.Post{ID: id, Body: "post", Version: 1})
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+		total int64
+	}{
+		{"idFrom=3&idTo=5&sort=id", []int{3, 4, 5}, 3},
+		{"idFrom=6&sort=id", []int{6, 7, 8}, 3},
+		{"idTo=2&sort=id", []int{1, 2}, 2},
+		{"idFrom=4&idTo=4", []int{4}, 1},
+		{"idFrom=2&idTo=7&sort=id&limit=2&offset=2", []int{4, 5}, 6},
+		{"idFrom=2&idTo=7&sort=id&order=desc&limit=2", []int{7, 6}, 6},
+		{"idFrom=20", []int{}, 0},
+	}
+	for _, tt := range tests {
+		ids, total := listIDs(t, tt.query)
+		if !slices.Equal(ids, tt.want) || total != tt.total {
+			t.Errorf("%q: got %v (total %d), want %v (total %d)", tt.query, ids, total, tt.want, tt.total)
+		}
+	}
+}
+
+func TestIDRangeValidatedAndKeyed(t *testing.T) {
+	for _, query := range []string{"idFrom=5&idTo=3", "idFrom=0", "idTo=-1", "idFrom=abc"} {
+		req := httptest.NewRequest(http.MethodGet, "/posts?"+query, nil)
+		if _, err := parseListQuery(req); err == nil {
+			t.Errorf("?%s accepted", query)
+		}
+	}
+
+	keys := map[string]bool{}
+	for _, query := range []string{"", "idFrom=3", "idTo=3", "idFrom=3&idTo=5", "idFrom=3&idTo=6"} {
+		q, err := parseListQuery(httptest.NewRequest(http.MethodGet, "/posts?"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[q.cacheKey()] = true
+	}
+	if len(keys) != 5 {
+		t.Errorf("%d distinct cache keys for 5 ranges", len(keys))
+	}
+}
+
+func TestAfterOverridesOffset(t *testing.T) {
+	dbtest.Connect(t)
+	for id := 1; id <= 6; id++ {
+		insertPost(t, models.Post{ID: id, Body: "post", Version: 1})
+	}
+
+	rec := serve(PostsHandler, http.MethodGet, "/posts?sort=id&after=2&offset=3&limit=2", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if w := rec.Header().Get("Warning"); !strings.Contains(w, "offset ignored") {
+		t.Errorf("Warning = %q, want the offset reported as ignored", w)
+	}
+	ids, _ := listIDs(t, "sort=id&after=2&offset=3&limit=2")
+	if !slices.Equal(ids, []int{3, 4}) {
+		t.Errorf("got %v, want the page right after post 2", ids)
+	}
+
+	// Without a conflict there's nothing to warn about
+	rec = serve(PostsHandler, http.MethodGet, "/posts?sort=id&after=2&offset=0", "")
+	if w := rec.Header().Get("Warning"); w != "" {
+		t.Errorf("Warning = %q with offset=0", w)
+	}
+}
+
+func TestInvalidAfter(t *testing.T) {
+	for _, after := range []string{"abc", "0", "-3", "1.5"} {
+		req := httptest.NewRequest(http.MethodGet, "/posts?after="+after, nil)
+		if _, err := parseListQuery(req); err == nil {
+			t.Errorf("after=%s accepted", after)
+		}
+	}
+
+	dbtest.Connect(t)
+	insertPost(t, models.Post{ID: 1, Body: "post", Version: 1})
+	// An anchor that never existed can't be placed in a non-id sort order
+	if rec := serve(PostsHandler, http.MethodGet, "/posts?sort=created_at&after=99", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown anchor: status %d, want 400: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestListOnFreshDatabase(t *testing.T) {
+	dbtest.Connect(t)
+
+	rec := serve(PostsHandler, http.MethodGet, "/posts", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), `"posts":[]`) || !strings.Contains(rec.Body.String(), `"totalPosts":0`) {
+		t.Errorf("empty list response: %s", rec.Body)
+	}
+}
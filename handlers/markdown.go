@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"fmt"
+	"go-server/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// handleGetPostMarkdown serves a post as a Markdown document whose YAML
+// frontmatter carries the metadata
+func handleGetPostMarkdown(w http.ResponseWriter, r *http.Request, id int) {
+	p, err := fetchPost(r.Context(), id)
+	if err != nil {
+		respondFetchError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write([]byte(renderMarkdown(p)))
+}
+
+func renderMarkdown(p models.Post) string {
+	tags := make([]string, len(p.Tags))
+	for i, tag := range p.Tags {
+		// A quoted string is valid YAML and keeps odd characters safe
+		tags[i] = strconv.Quote(tag)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	fmt.Fprintf(&sb, "id: %d\n", p.ID)
+	fmt.Fprintf(&sb, "created_at: %s\n", p.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&sb, "tags: [%s]\n", strings.Join(tags, ", "))
+	sb.WriteString("---\n\n")
+	sb.WriteString(p.Body)
+	sb.WriteString("\n")
+	return sb.String()
+}
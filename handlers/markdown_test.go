@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"go-server/cache"
+	"go-server/cache/cachetest"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	p := models.Post{
+		ID:        12,
+		Body:      "# Hello\n\nWorld",
+		Tags:      []string{"go", `say "hi"`},
+		CreatedAt: time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600)),
+	}
+	want := "---\n" +
+		"id: 12\n" +
+		"created_at: 2024-03-01T08:30:00Z\n" +
+		`tags: ["go", "say \"hi\""]` + "\n" +
+		"---\n\n" +
+		"# Hello\n\nWorld\n"
+	if got := renderMarkdown(p); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := renderMarkdown(models.Post{ID: 1, Body: "b"}); !strings.Contains(got, "\ntags: []\n") {
+		t.Errorf("untagged post rendered as:\n%s", got)
+	}
+}
+
+func TestMarkdownEndpoint(t *testing.T) {
+	cachetest.Start(t)
+	created := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	cache.CachePost(models.Post{ID: 5, Body: "text", Tags: []string{"a"}, CreatedAt: created, UpdatedAt: created, Version: 1})
+
+	rec := serve(PostHandler, http.MethodGet, "/posts/5.md", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/markdown; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/markdown; charset=utf-8", got)
+	}
+	want := "---\nid: 5\ncreated_at: 2024-03-01T08:30:00Z\ntags: [\"a\"]\n---\n\ntext\n"
+	if rec.Body.String() != want {
+		t.Errorf("body\n%s\nwant\n%s", rec.Body, want)
+	}
+
+	rec = serve(PostHandler, http.MethodGet, "/posts/5", "", "Accept", "text/markdown")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/markdown; charset=utf-8" {
+		t.Errorf("Accept: text/markdown gave %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestMarkdownMissingPost(t *testing.T) {
+	dbtest.Connect(t)
+	if rec := serve(PostHandler, http.MethodGet, "/posts/404.md", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("status %d, want 404", rec.Code)
+	}
+}
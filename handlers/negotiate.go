@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"go-server/utils"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	mediaJSON     = "application/json"
+	mediaMarkdown = "text/markdown"
+	mediaText     = "text/plain"
+)
+
+// strictAccept answers 406 when nothing in Accept can be served. When off,
+// such requests get the endpoint's default representation instead.
+var strictAccept = true
+
+// InitNegotiation reads STRICT_ACCEPT (default true)
+func InitNegotiation() {
+	raw := os.Getenv("STRICT_ACCEPT")
+	if raw == "" {
+		return
+	}
+	strict, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Fatalf("Invalid STRICT_ACCEPT %q: must be true or false", raw)
+	}
+	strictAccept = strict
+}
+
+type NotAcceptableResponse struct {
+	utils.ErrorResponse
+	Supported []string `json:"supported"`
+}
+
+// negotiate picks the representation to serve from supported, in the
+// server's order of preference, using the request's Accept header. When it
+// returns false a 406 has already been written.
+func negotiate(w http.ResponseWriter, r *http.Request, supported ...string) (string, bool) {
+	if len(supported) > 1 {
+		w.Header().Add("Vary", "Accept")
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return supported[0], true
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range supported {
+		if q := acceptQuality(accept, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	if best != "" {
+		return best, true
+	}
+	if !strictAccept {
+		return supported[0], true
+	}
+
+	utils.RespondWithStatus(w, http.StatusNotAcceptable, NotAcceptableResponse{
+		ErrorResponse: utils.ErrorResponse{
+			Code:    utils.CodeNotAcceptable,
+			Message: "None of the requested media types can be served",
+			Status:  http.StatusNotAcceptable,
+		},
+		Supported: supported,
+	})
+	return "", false
+}
+
+// acceptQuality is the q-value the Accept header gives offer, taking the most
+// specific matching range; 0 means not acceptable
+func acceptQuality(accept, offer string) float64 {
+	offerType, offerSub, _ := strings.Cut(offer, "/")
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		rangeType, rangeSub, _ := strings.Cut(mediaRange, "/")
+
+		var s int
+		switch {
+		case rangeType == offerType && rangeSub == offerSub:
+			s = 2
+		case rangeType == offerType && rangeSub == "*":
+			s = 1
+		case rangeType == "*" && rangeSub == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+
+		rangeQ := 1.0
+		if raw, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+				rangeQ = parsed
+			}
+		}
+		q, specificity = rangeQ, s
+	}
+	return q
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/cache"
+	"go-server/cache/cachetest"
+	"go-server/models"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUnsupportedAcceptIsNotAcceptable(t *testing.T) {
+	cachetest.Start(t)
+	created := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	cache.CachePost(models.Post{ID: 5, Body: "text", CreatedAt: created, UpdatedAt: created, Version: 1})
+
+	rec := serve(PostHandler, http.MethodGet, "/posts/5", "", "Accept", "application/pdf")
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("status %d, want 406: %s", rec.Code, rec.Body)
+	}
+	var resp NotAcceptableResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body, err)
+	}
+	want := []string{mediaJSON, mediaMarkdown, mediaText}
+	if !reflect.DeepEqual(resp.Supported, want) {
+		t.Errorf("supported = %v, want %v", resp.Supported, want)
+	}
+	if got := rec.Header().Get("Vary"); got != "Accept" {
+		t.Errorf("Vary = %q, want Accept", got)
+	}
+
+	strictAccept = false
+	t.Cleanup(func() { strictAccept = true })
+	rec = serve(PostHandler, http.MethodGet, "/posts/5", "", "Accept", "application/pdf")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != mediaJSON {
+		t.Errorf("lenient negotiation gave %d %q, want 200 %s", rec.Code, rec.Header().Get("Content-Type"), mediaJSON)
+	}
+}
+
+func TestAcceptQuality(t *testing.T) {
+	tests := []struct {
+		accept, offer string
+		want          float64
+	}{
+		{"application/json", mediaJSON, 1},
+		{"text/*;q=0.5", mediaMarkdown, 0.5},
+		{"*/*;q=0.1, text/plain", mediaText, 1},
+		{"text/plain;q=0, */*", mediaText, 0},
+		{"application/pdf", mediaJSON, 0},
+	}
+	for _, tt := range tests {
+		if got := acceptQuality(tt.accept, tt.offer); got != tt.want {
+			t.Errorf("acceptQuality(%q, %q) = %v, want %v", tt.accept, tt.offer, got, tt.want)
+		}
+	}
+}
@@ -3,20 +3,23 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"go-server/cache"
 	"go-server/db"
+	"go-server/events"
+	"go-server/metrics"
 	"go-server/models"
 	"go-server/utils"
-	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-redis/redis"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var (
@@ -26,50 +29,193 @@ var (
 )
 
 type PaginatedResponse struct {
-	Posts      []models.Post `json:"posts"`
-	TotalPosts int64         `json:"totalPosts"`
-	Limit      int           `json:"limit"`
-	Offset     int           `json:"offset"`
+	Posts      interface{} `json:"posts"` // []models.Post, or field maps when ?fields= is set
+	TotalPosts int64       `json:"totalPosts"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+}
+
+type ValidationResponse struct {
+	Valid  bool                `json:"valid"`
+	Code   utils.ErrorCode     `json:"code,omitempty"`
+	Errors []models.FieldError `json:"errors,omitempty"`
+}
+
+// respondValidationErrors answers 422 with the per-field errors
+func respondValidationErrors(w http.ResponseWriter, errs []models.FieldError) {
+	utils.RespondWithStatus(w, http.StatusUnprocessableEntity, ValidationResponse{
+		Valid:  false,
+		Code:   utils.CodeValidationFailed,
+		Errors: errs,
+	})
 }
 
 const (
 	postCachePrefix = "post:"
 	allPostsKey     = "all_posts"
 	cacheDuration   = 10 * time.Minute
+	dbTimeout       = 5 * time.Second
 )
 
+// dbContext bounds database work by dbTimeout. It keeps the parent's values
+// (such as the DB op counter) but not its cancellation, so a client hanging
+// up doesn't abort a write halfway through.
+func dbContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(parent), dbTimeout)
+}
+
+// cacheDisabled is the X-Cache value for cacheable reads served while the
+// cache is down or switched off for the route
+const cacheDisabled = "DISABLED"
+
+var errDBUnavailable = errors.New("database unavailable")
+
+// requireDB answers 503 when the server booted without MongoDB
+func requireDB(w http.ResponseWriter) bool {
+	if db.PostCol == nil {
+		utils.RespondWithError(w, http.StatusServiceUnavailable, utils.CodeDBUnavailable, "Database unavailable")
+		return false
+	}
+	return true
+}
+
 // Handling function for /posts endpoint
 func PostsHandler(w http.ResponseWriter, r *http.Request) { // (return JSON, information about the incoming request)
 	// check the HTTP requests methods
 	switch r.Method {
 	// if it's GET --> call the function to handle get request
 	case "GET":
+		if utils.QueryParam(r, "q") != "" {
+			handleSearchPosts(w, r)
+			return
+		}
 		handleGetPosts(w, r)
 	case "POST":
 		handlePostPosts(w, r)
+	case "DELETE":
+		handleBulkDeletePosts(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
 func PostHandler(w http.ResponseWriter, r *http.Request) { // (return JSON, information about the incoming request)
-	// Debug printing
-	idStr := r.URL.Path[len("/posts/"):]
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+	// Path is /posts/{id}, /posts/{id}.md or /posts/{id}/{action}
+	idStr, action, _ := strings.Cut(r.URL.Path[len("/posts/"):], "/")
+	if strings.HasSuffix(idStr, ".md") && action == "" {
+		idStr, action = strings.TrimSuffix(idStr, ".md"), ".md"
+	}
+	id, ok := resolvePostID(w, r, idStr)
+	if !ok {
+		return
+	}
+
+	switch action {
+	case "":
+	case ".md":
+		if r.Method != http.MethodGet {
+			utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleGetPostMarkdown(w, r, id)
+		return
+	case "raw":
+		if r.Method != http.MethodGet {
+			utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleGetPostRaw(w, r, id)
+		return
+	case "body":
+		if r.Method != http.MethodPatch {
+			utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handlePatchPostBody(w, r, id)
+		return
+	case "touch":
+		if r.Method != http.MethodPost {
+			utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleTouchPost(w, r, id)
+		return
+	case "context":
+		if r.Method != http.MethodGet {
+			utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handleGetPostContext(w, r, id)
+		return
+	default:
+		utils.Error(w, "Not found", http.StatusNotFound)
 		return
 	}
+
 	switch r.Method {
 	case http.MethodGet:
-		handleGetPost(w, r, id)
+		media, ok := negotiate(w, r, mediaJSON, mediaMarkdown, mediaText)
+		if !ok {
+			return
+		}
+		switch media {
+		case mediaMarkdown:
+			handleGetPostMarkdown(w, r, id)
+		case mediaText:
+			handleGetPostRaw(w, r, id)
+		default:
+			handleGetPost(w, r, id)
+		}
 	case http.MethodDelete:
 		handleDeletePost(w, r, id)
 	case http.MethodPut:
 		handleEditPost(w, r, id)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Handling function for /posts/validate endpoint
+// Runs the same checks as create without touching the database
+func ValidatePostHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := decodeNewPost(w, r); !ok {
+		return
 	}
+	utils.RespondWithJSON(w, ValidationResponse{Valid: true})
+}
+
+// decodeNewPost reads a create payload and checks it against the post schema
+// and the model rules. When it returns false the error response has already
+// been written.
+func decodeNewPost(w http.ResponseWriter, r *http.Request) (models.Post, bool) {
+	var p models.Post
+	body, ok := readJSONBody(w, r)
+	if !ok {
+		return p, false
+	}
+
+	if errs := models.ValidateCreatePayload(body); len(errs) > 0 {
+		respondValidationErrors(w, errs)
+		return p, false
+	}
+
+	if err := json.Unmarshal(body, &p); err != nil {
+		log.Printf("Error unmarshaling JSON: %v", err)
+		utils.Error(w, "Invalid request body", http.StatusBadRequest)
+		return p, false
+	}
+
+	p.Body = models.NormalizeBody(p.Body)
+	if errs := p.Validate(); len(errs) > 0 {
+		respondValidationErrors(w, errs)
+		return p, false
+	}
+	return p, true
 }
 
 func handleGetPosts(w http.ResponseWriter, r *http.Request) {
@@ -81,173 +227,338 @@ func handleGetPosts(w http.ResponseWriter, r *http.Request) {
 
 	// defer postsMu.Unlock() // defer until the code finished executing
 
-	// Try to get from cache first
-	if cachedPosts, found := cache.GetCachedAllPosts(); found {
-		utils.RespondWithJSON(w, cachedPosts)
+	if _, ok := negotiate(w, r, mediaJSON); !ok {
+		return
+	}
+
+	q, err := parseListQuery(r)
+	if err != nil {
+		utils.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if q.OffsetIgnored {
+		w.Header().Set("Warning", `299 - "offset ignored because after is set"`)
+	}
+	cacheKey := cache.BuildPostsListKey(q.cacheKey())
+	useCache := cache.EnabledFor(cache.RouteList)
 
-	limit, offset := utils.ParsePaginationParams(r)
-	findOptions := options.Find().SetLimit(int64(limit)).SetSkip(int64(offset)).SetSort(bson.D{{"id", 1}})
+	// Try to get from cache first
+	if useCache {
+		if page, age, found := cache.GetCachedPostsList(cacheKey); found {
+			metrics.CacheHit(metrics.EndpointList)
+			cache.Trace(r.Context(), "redis", "hit")
+			w.Header().Set("X-Cache-Age", strconv.Itoa(int(age.Seconds())))
+			utils.RespondWithRawJSON(w, page)
+			return
+		}
+		metrics.CacheMiss(metrics.EndpointList)
+		cache.Trace(r.Context(), "redis", "miss")
+	} else {
+		w.Header().Set("X-Cache", cacheDisabled)
+		cache.Trace(r.Context(), "redis", "disabled")
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if !requireDB(w) {
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
 	defer cancel()
 
-	cursor, err := db.PostCol.Find(ctx, bson.M{}, findOptions)
-	if err != nil {
-		http.Error(w, "Error fetching posts", http.StatusInternalServerError)
+	release, ok := acquireCursorSlot(w)
+	if !ok {
 		return
 	}
-	defer cursor.Close(ctx)
+	defer release()
 
-	var ps []models.Post
-	if err := cursor.All(ctx, &ps); err != nil {
-		http.Error(w, "Error decoding posts", http.StatusInternalServerError)
+	if err := q.resolveAfter(ctx); err != nil {
+		if errors.Is(err, errUnknownAnchor) {
+			utils.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error resolving after cursor %d: %v", q.After, err)
+		utils.Error(w, "Error fetching posts", http.StatusInternalServerError)
+		return
+	}
+
+	filter := db.Active(q.filter())
+	ps, err := db.FindPosts(ctx, db.Active(q.pageFilter()), q.findOptions())
+	if err != nil {
+		respondListError(w, err)
 		return
 	}
 
-	cache.CacheAllPosts(ps)
+	count, _ := db.Count(ctx, filter)
+	page, err := renderListPage(q, ps, count)
+	if err != nil {
+		log.Printf("Error encoding posts page: %v", err)
+		utils.Error(w, "Error fetching posts", http.StatusInternalServerError)
+		return
+	}
+	cache.Trace(r.Context(), "db", "hit")
+	if useCache {
+		cache.CachePostsList(cacheKey, page)
+		cache.Trace(r.Context(), "redis", "store")
+	}
+	utils.RespondWithRawJSON(w, page)
+}
 
-	count, _ := db.PostCol.CountDocuments(ctx, bson.M{})
-	utils.RespondWithJSON(w, PaginatedResponse{Posts: ps, TotalPosts: count, Limit: limit, Offset: offset})
+// renderListPage encodes a GET /posts response. The cache stores exactly these
+// bytes, so cached and uncached responses can't drift apart.
+func renderListPage(q listQuery, ps []models.Post, total int64) (json.RawMessage, error) {
+	return json.Marshal(PaginatedResponse{Posts: q.shape(ps), TotalPosts: total, Limit: q.Limit, Offset: q.Offset})
 }
 
 func handlePostPosts(w http.ResponseWriter, r *http.Request) {
-	var p models.Post
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Error reading request body: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	p, ok := decodeNewPost(w, r)
+	if !ok {
 		return
 	}
-	
-	if err := json.Unmarshal(body, &p); err != nil {
-		log.Printf("Error unmarshaling JSON: %v", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+
+	if !requireDB(w) {
 		return
 	}
 
 	postsMu.Lock()
 	defer postsMu.Unlock()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := dbContext(r.Context())
 	defer cancel()
 
-	// Get the next available ID from the database
-	var maxIDResult struct {
-		MaxID int `bson:"maxID"`
-	}
-	pipeline := []bson.M{
-		{"$sort": bson.M{"id": -1}},
-		{"$limit": 1},
-		{"$project": bson.M{"maxID": "$id"}},
-	}
-
-	cursor, err := db.PostCol.Aggregate(ctx, pipeline)
+	id, err := nextPostID(ctx)
 	if err != nil {
 		log.Printf("Error getting max ID: %v", err)
-		http.Error(w, "Error creating post", http.StatusInternalServerError)
+		utils.Error(w, "Error creating post", http.StatusInternalServerError)
 		return
 	}
-	defer cursor.Close(ctx)
+	p.ID = id
+	p.ObjectID = primitive.NewObjectID()
 
-	if cursor.Next(ctx) {
-		if err := cursor.Decode(&maxIDResult); err != nil {
-			log.Printf("Error decoding max ID: %v", err)
-			http.Error(w, "Error creating post", http.StatusInternalServerError)
-			return
-		}
-		p.ID = maxIDResult.MaxID + 1
-	} else {
-		p.ID = 1 // If no posts exist, start with ID 1
-	}
-
-	if db.PostCol == nil {
-		log.Printf("MongoDB collection is nil")
-		http.Error(w, "Database connection error", http.StatusInternalServerError)
-		return
-	}
+	p.CreatedAt = models.Now()
+	p.UpdatedAt = p.CreatedAt
+	p.Version = 1
 
 	insertResult, err := db.PostCol.InsertOne(ctx, p)
 	if err != nil {
 		log.Printf("Error inserting post: %v", err)
-		http.Error(w, "Error creating post", http.StatusInternalServerError)
+		utils.Error(w, "Error creating post", http.StatusInternalServerError)
 		return
 	}
 
 	log.Printf("Successfully inserted post with ID: %v", insertResult.InsertedID)
-	cache.InvalidatePostCache(p.ID)
+	events.Publish(events.Event{Type: events.PostCreated, PostID: p.ID, Post: p})
 	utils.RespondWithStatus(w, http.StatusCreated, p)
 }
 
+// respondListError maps a db.FindPosts error to an HTTP status
+func respondListError(w http.ResponseWriter, err error) {
+	if errors.Is(err, db.ErrResultTooLarge) {
+		utils.Error(w, "Result too large, request a smaller limit", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Error fetching posts: %v", err)
+	utils.Error(w, "Error fetching posts", http.StatusInternalServerError)
+}
+
+// nextPostID reserves a new post id. Ids are handed out by an atomic counter,
+// so they increase monotonically and are never reused.
+func nextPostID(ctx context.Context) (int, error) {
+	return db.NextPostID(ctx)
+}
+
 func handleGetPost(w http.ResponseWriter, r *http.Request, id int) {
 	start := time.Now()
-	if post, found := cache.GetCachedPost(id); found {
-		cachedPost := models.Post{
-			ID:   post.ID,
-			Body: post.Body,
+	meta := true
+	if raw := utils.QueryParam(r, "meta"); raw != "" {
+		var err error
+		if meta, err = strconv.ParseBool(raw); err != nil {
+			utils.Error(w, "meta must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+	if raw := utils.QueryParam(r, "countView"); raw != "" {
+		countView, err := strconv.ParseBool(raw)
+		if err != nil {
+			utils.Error(w, "countView must be true or false", http.StatusBadRequest)
+			return
+		}
+		if countView {
+			handleCountView(w, r, id, start, meta)
+			return
 		}
-
-		utils.RespondWithMetadata(w, cachedPost, "cache", time.Since(start).Milliseconds(), true)
-		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	if cache.EnabledFor(cache.RoutePost) {
+		if post, age, found := cache.GetCachedPost(id); found {
+			metrics.CacheHit(metrics.EndpointPost)
+			cache.Trace(r.Context(), "redis", "hit")
+			w.Header().Set("X-Cache-Age", strconv.Itoa(int(age.Seconds())))
+			if readMode == ReadModeCacheFirstAsync {
+				// Weak read: the caller gets the cached copy now, MongoDB is checked after
+				w.Header().Set("X-Read-Consistency", "weak")
+				cache.Trace(r.Context(), "refresh", "async")
+				refreshPostAsync(id)
+			}
+			recordView(r, id)
+			respondPost(w, post, "cache", start, true, meta)
+			return
+		}
+		metrics.CacheMiss(metrics.EndpointPost)
+		cache.Trace(r.Context(), "redis", "miss")
+	} else {
+		w.Header().Set("X-Cache", cacheDisabled)
+		cache.Trace(r.Context(), "redis", "disabled")
+	}
 
-	var p models.Post
-	if err := db.PostCol.FindOne(ctx, bson.M{"id": id}).Decode(&p); err != nil {
-		http.Error(w, "Post not found", http.StatusNotFound)
+	p, err := loadPost(r.Context(), id)
+	if err != nil {
+		if serveStale(r.Context(), w, id, start, meta, err) {
+			return
+		}
+		respondFetchError(w, err)
 		return
 	}
-	cachePost := cache.Post{
-		ID:   p.ID,
-		Body: p.Body,
+	recordView(r, id)
+	respondPost(w, p, "database", start, false, meta)
+}
+
+// respondPost writes a single post with its X-Cache and X-Response-Time-Ms
+// headers. The body is the source/timing envelope unless the client asked for
+// ?meta=false, in which case it's the bare post.
+func respondPost(w http.ResponseWriter, p models.Post, source string, start time.Time, fromCache, meta bool) {
+	elapsed := time.Since(start).Milliseconds()
+	if meta {
+		utils.RespondWithMetadata(w, p, source, elapsed, fromCache)
+		return
 	}
-	cache.CachePost(cachePost)
-	utils.RespondWithMetadata(w, p, "database", time.Since(start).Milliseconds(), false)
+	utils.SetMetadataHeaders(w, elapsed, fromCache)
+	utils.RespondWithJSON(w, p)
 }
 
 func handleDeletePost(w http.ResponseWriter, r *http.Request, id int) {
+	if !requireDB(w) {
+		return
+	}
+
 	postsMu.Lock()
 	defer postsMu.Unlock()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := dbContext(r.Context())
 	defer cancel()
 
-	res, err := db.PostCol.DeleteOne(ctx, bson.M{"id": id})
-	if err != nil || res.DeletedCount == 0 {
-		http.Error(w, "Post not found", http.StatusNotFound)
+	// Soft delete: keep a tombstone so sync clients see the deletion
+	now := models.Now()
+	tombstone := bson.M{
+		"$set": bson.M{"deleted_at": now, "updated_at": now},
+		"$inc": bson.M{"version": 1},
+	}
+	res, err := db.PostCol.UpdateOne(ctx, db.Active(unmodifiedSinceFilter(r, bson.M{"id": id})), tombstone)
+	if err != nil {
+		log.Printf("Error deleting post %d: %v", id, err)
+		utils.Error(w, "Error deleting post", http.StatusInternalServerError)
+		return
+	}
+	if res.MatchedCount == 0 {
+		// Already gone is success for a retried DELETE unless STRICT_DELETE is set
+		if !strictDelete && !preconditionFailed(ctx, r, id) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		respondNotFoundOrPreconditionFailed(ctx, w, r, id)
 		return
 	}
 
-	cache.InvalidatePostCache(id)
+	events.Publish(events.Event{Type: events.PostDeleted, PostID: id})
+	if !strictDelete {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
 	w.Write([]byte(`{"message": "Post deleted successfully"}`))
 }
 
 func handleEditPost(w http.ResponseWriter, r *http.Request, id int) { // (return JSON, information about the incoming request)
+	if !requireDB(w) {
+		return
+	}
+
+	body, ok := readJSONBody(w, r)
+	if !ok {
+		return
+	}
+
+	if !checkUpdateFieldCount(w, body) {
+		return
+	}
+	if !checkEditableFields(w, body) {
+		return
+	}
+	if isEmptyUpdate(body) {
+		if emptyUpdatePolicy == EmptyUpdateReject {
+			utils.RespondWithError(w, http.StatusBadRequest, utils.CodeEmptyUpdate, "No fields to update")
+			return
+		}
+		p, err := loadPost(r.Context(), id)
+		if err != nil {
+			respondFetchError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, p)
+		return
+	}
+
+	if errs := models.ValidateUpdatePayload(body); len(errs) > 0 {
+		respondValidationErrors(w, errs)
+		return
+	}
 
 	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := json.Unmarshal(body, &updates); err != nil {
+		utils.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if b, ok := updates["body"].(string); ok {
+		normalized := models.Post{Body: models.NormalizeBody(b)}
+		if errs := normalized.Validate(); len(errs) > 0 {
+			respondValidationErrors(w, errs)
+			return
+		}
+		updates["body"] = normalized.Body
+	}
+	if tags, ok := updates["tags"].([]interface{}); ok {
+		changed := models.Post{Tags: make([]string, 0, len(tags))}
+		for _, t := range tags {
+			s, _ := t.(string)
+			changed.Tags = append(changed.Tags, s)
+		}
+		if errs := changed.ValidateLimits(); len(errs) > 0 {
+			respondValidationErrors(w, errs)
+			return
+		}
+	}
+
+	ctx, cancel := dbContext(r.Context())
 	defer cancel()
 
-	update := bson.M{"$set": updates}
-	res, err := db.PostCol.UpdateOne(ctx, bson.M{"id": id}, update)
-	if err != nil || res.MatchedCount == 0 {
-		http.Error(w, "Post not found", http.StatusNotFound)
+	updates["updated_at"] = models.Now()
+	update := bson.M{"$set": updates, "$inc": bson.M{"version": 1}}
+	res, err := db.PostCol.UpdateOne(ctx, db.Active(unmodifiedSinceFilter(r, bson.M{"id": id})), update)
+	if err != nil {
+		log.Printf("Error updating post %d: %v", id, err)
+		utils.Error(w, "Error updating post", http.StatusInternalServerError)
+		return
+	}
+	if res.MatchedCount == 0 {
+		respondNotFoundOrPreconditionFailed(ctx, w, r, id)
 		return
 	}
-
-	cache.InvalidatePostCache(id)
 
 	var updatedPost models.Post
-	if err := db.PostCol.FindOne(ctx, bson.M{"id": id}).Decode(&updatedPost); err != nil {
-		http.Error(w, "Error retrieving updated post", http.StatusInternalServerError)
+	findErr := db.FindOne(ctx, db.Active(bson.M{"id": id}), &updatedPost)
+	// Publish even if the re-read failed; subscribers only need the id
+	events.Publish(events.Event{Type: events.PostUpdated, PostID: id, Post: updatedPost})
+	if findErr != nil {
+		utils.Error(w, "Error retrieving updated post", http.StatusInternalServerError)
 		return
 	}
 	utils.RespondWithJSON(w, updatedPost)
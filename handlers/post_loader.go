@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go-server/cache"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"net/http"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	errPostNotFound = errors.New("post not found")
+
+	// postLoads coalesces concurrent cache misses for the same post into a
+	// single MongoDB query
+	postLoads singleflight.Group
+)
+
+// resolvePostID turns the {id} path segment into a numeric post id. It is
+// either the numeric id itself or the post's 24-character hex ObjectID, which
+// is looked up in MongoDB. When it returns false the error response has
+// already been written.
+func resolvePostID(w http.ResponseWriter, r *http.Request, raw string) (int, bool) {
+	if id, err := strconv.Atoi(raw); err == nil {
+		return id, true
+	}
+	oid, err := primitive.ObjectIDFromHex(raw)
+	if err != nil {
+		utils.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return 0, false
+	}
+	if !requireDB(w) {
+		return 0, false
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	var p models.Post
+	err = db.PostCol.FindOne(ctx, db.Active(bson.M{"_id": oid}), options.FindOne().SetProjection(bson.M{"id": 1})).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		respondFetchError(w, errPostNotFound)
+		return 0, false
+	}
+	if err != nil {
+		respondFetchError(w, err)
+		return 0, false
+	}
+	return p.ID, true
+}
+
+// fetchPost loads a post through the cache, falling back to MongoDB
+func fetchPost(ctx context.Context, id int) (models.Post, error) {
+	if cache.EnabledFor(cache.RoutePost) {
+		if post, _, found := cache.GetCachedPost(id); found {
+			cache.Trace(ctx, "redis", "hit")
+			return post, nil
+		}
+		cache.Trace(ctx, "redis", "miss")
+	}
+	return loadPost(ctx, id)
+}
+
+// loadPost reads a post from MongoDB and caches it. Concurrent callers asking
+// for the same id share one query, so a burst of misses on a cold key can't
+// stampede the database; the query runs under the first caller's context.
+func loadPost(parent context.Context, id int) (models.Post, error) {
+	if db.PostCol == nil {
+		return models.Post{}, errDBUnavailable
+	}
+
+	v, err, shared := postLoads.Do(strconv.Itoa(id), func() (interface{}, error) {
+		ctx, cancel := dbContext(parent)
+		defer cancel()
+
+		var p models.Post
+		if err := db.FindOne(ctx, db.Active(bson.M{"id": id}), &p); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				cache.Trace(parent, "db", "miss")
+				return nil, errPostNotFound
+			}
+			cache.Trace(parent, "db", "error")
+			return nil, err
+		}
+		cache.Trace(parent, "db", "hit")
+
+		if cache.EnabledFor(cache.RoutePost) {
+			cache.CachePost(p)
+			cache.Trace(parent, "redis", "store")
+		}
+		if serveStaleOnError {
+			cache.CacheStalePost(p)
+		}
+		return p, nil
+	})
+	if shared {
+		// Only the caller that ran the query has a db step in its trace
+		cache.Trace(parent, "singleflight", "shared")
+	}
+	if err != nil {
+		return models.Post{}, err
+	}
+	return v.(models.Post), nil
+}
+
+// respondFetchError maps a fetchPost or loadPost error to an HTTP status
+func respondFetchError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errPostNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, utils.CodePostNotFound, "Post not found")
+	case errors.Is(err, errDBUnavailable):
+		utils.RespondWithError(w, http.StatusServiceUnavailable, utils.CodeDBUnavailable, "Database unavailable")
+	default:
+		utils.Error(w, "Error fetching post", http.StatusInternalServerError)
+	}
+}
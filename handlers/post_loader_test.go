@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestResolvePostIDWithoutDatabase(t *testing.T) {
+	// Numeric ids never touch MongoDB, so no database is needed
+	rec := httptest.NewRecorder()
+	id, ok := resolvePostID(rec, httptest.NewRequest(http.MethodGet, "/posts/42", nil), "42")
+	if !ok || id != 42 {
+		t.Errorf("got %d, %v; want 42", id, ok)
+	}
+
+	for _, raw := range []string{"abc", "4.2", "0123456789abcdef0123456", "0123456789abcdef012345678"} {
+		rec := httptest.NewRecorder()
+		if _, ok := resolvePostID(rec, httptest.NewRequest(http.MethodGet, "/posts/x", nil), raw); ok || rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: ok %v, status %d; want 400", raw, ok, rec.Code)
+		}
+	}
+}
+
+func TestPostByObjectID(t *testing.T) {
+	dbtest.Connect(t)
+	created := createPost(t, "by object id")
+	if created.ObjectID.IsZero() {
+		t.Fatal("created post has no objectId")
+	}
+
+	rec := serve(PostHandler, http.MethodGet, "/posts/"+created.ObjectID.Hex()+"?meta=false", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var got models.Post
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != created.ID || got.ObjectID != created.ObjectID {
+		t.Errorf("got post %d (%s), want %d (%s)", got.ID, got.ObjectID.Hex(), created.ID, created.ObjectID.Hex())
+	}
+
+	if rec := serve(PostHandler, http.MethodGet, "/posts/"+primitive.NewObjectID().Hex(), ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown objectId: status %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-server/cache"
+	"go-server/cache/cachetest"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/metrics"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestListCacheHitSendsAge(t *testing.T) {
+	cachetest.Start(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/posts?limit=5", nil)
+	q, err := parseListQuery(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.CachePostsList(cache.BuildPostsListKey(q.cacheKey()), json.RawMessage(`{"posts":[],"total":0}`))
+
+	rec := httptest.NewRecorder()
+	PostsHandler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("X-Cache-Age"); got != "0" {
+		t.Errorf("X-Cache-Age = %q on a fresh list hit, want 0", got)
+	}
+}
+
+func validatePost(t *testing.T, body string) (int, ValidationResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/posts/validate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	ValidatePostHandler(rec, req)
+	var resp ValidationResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp
+}
+
+func TestListCacheHitMatchesDatabaseResponse(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+	for id := 1; id <= 4; id++ {
+		insertPost(t, models.Post{ID: id, Body: "post", Tags: []string{"go"}, Version: 1})
+	}
+
+	for _, target := range []string{"/posts?limit=2&offset=1", "/posts?limit=2"} {
+		miss := serve(PostsHandler, http.MethodGet, target, "")
+		hit := serve(PostsHandler, http.MethodGet, target, "")
+		if miss.Code != http.StatusOK || hit.Code != http.StatusOK {
+			t.Fatalf("%s: status %d then %d", target, miss.Code, hit.Code)
+		}
+		if miss.Header().Get("X-Cache-Age") != "" || hit.Header().Get("X-Cache-Age") == "" {
+			t.Fatalf("%s: want a miss then a hit", target)
+		}
+		if miss.Body.String() != hit.Body.String() {
+			t.Errorf("%s: cached response differs\ndatabase: %s\ncache:    %s", target, miss.Body, hit.Body)
+		}
+		if !strings.Contains(hit.Body.String(), `"totalPosts":4,"limit":2`) {
+			t.Errorf("%s: response is not a full page: %s", target, hit.Body)
+		}
+	}
+}
+
+// cacheEvents wires cache invalidation to post events, as main does
+var cacheEvents sync.Once
+
+func TestListPagesCachedSeparately(t *testing.T) {
+	dbtest.Connect(t)
+	mr := cachetest.Start(t)
+	cacheEvents.Do(cache.SubscribeToPostEvents)
+	for range 10 {
+		createPost(t, "post")
+	}
+	listKeys := func() []string {
+		var keys []string
+		for _, k := range mr.Keys() {
+			if strings.HasPrefix(k, "posts:list:") {
+				keys = append(keys, k)
+			}
+		}
+		return keys
+	}
+
+	for range 2 {
+		first, _ := listIDs(t, "limit=5&offset=0")
+		second, _ := listIDs(t, "limit=5&offset=5")
+		if !slices.Equal(first, []int{1, 2, 3, 4, 5}) || !slices.Equal(second, []int{6, 7, 8, 9, 10}) {
+			t.Fatalf("pages %v and %v", first, second)
+		}
+	}
+	if keys := listKeys(); len(keys) != 2 {
+		t.Errorf("list cache keys %v, want one per page", keys)
+	}
+
+	createPost(t, "another")
+	if keys := listKeys(); len(keys) != 0 {
+		t.Errorf("list cache keys %v survived a create", keys)
+	}
+}
+
+func TestValidatePostAcceptsValidPayload(t *testing.T) {
+	code, resp := validatePost(t, `{"body":"hello","tags":["go"]}`)
+	if code != http.StatusOK || !resp.Valid {
+		t.Errorf("status %d, valid %v; want 200 and valid", code, resp.Valid)
+	}
+}
+
+func TestValidatePostReportsInvalidPayloads(t *testing.T) {
+	tests := map[string]string{
+		"missing body": `{"tags":["go"]}`,
+		"blank body":   `{"body":"   "}`,
+		"wrong type":   `{"body":42}`,
+	}
+	for name, payload := range tests {
+		t.Run(name, func(t *testing.T) {
+			code, resp := validatePost(t, payload)
+			if code != http.StatusUnprocessableEntity {
+				t.Fatalf("status %d, want 422", code)
+			}
+			if resp.Valid || len(resp.Errors) == 0 || resp.Errors[0].Field != "/body" {
+				t.Errorf("response %+v, want an error for /body", resp)
+			}
+		})
+	}
+}
+
+func TestValidatePostReportsFieldLimits(t *testing.T) {
+	tests := map[string]struct {
+		payload, field string
+	}{
+		"body": {`{"body":"` + strings.Repeat("x", 10001) + `"}`, "/body"},
+		"tag":  {`{"body":"b","tags":["go","` + strings.Repeat("x", 51) + `"]}`, "/tags/1"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			code, resp := validatePost(t, tt.payload)
+			if code != http.StatusUnprocessableEntity {
+				t.Fatalf("status %d, want 422", code)
+			}
+			if len(resp.Errors) != 1 || resp.Errors[0].Field != tt.field {
+				t.Errorf("errors %+v, want one for %s", resp.Errors, tt.field)
+			}
+		})
+	}
+}
+
+// serve runs h on a request with a JSON body, when one is given, and any
+// extra headers as name, value pairs
+func serve(h http.HandlerFunc, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, target, nil)
+	} else {
+		req = httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+func TestCacheMetricsPerEndpoint(t *testing.T) {
+	cachetest.Start(t)
+	lookups := func() [4]float64 {
+		return [4]float64{
+			metrics.CacheLookups.Value(metrics.EndpointPost, "hit"),
+			metrics.CacheLookups.Value(metrics.EndpointPost, "miss"),
+			metrics.CacheLookups.Value(metrics.EndpointList, "hit"),
+			metrics.CacheLookups.Value(metrics.EndpointList, "miss"),
+		}
+	}
+	cache.CachePost(models.Post{ID: 1, Body: "b", Version: 1})
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	q, err := parseListQuery(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.CachePostsList(cache.BuildPostsListKey(q.cacheKey()), json.RawMessage(`{"posts":[]}`))
+
+	before := lookups()
+	serve(PostHandler, http.MethodGet, "/posts/1", "")
+	serve(PostHandler, http.MethodGet, "/posts/1", "")
+	serve(PostsHandler, http.MethodGet, "/posts", "")
+	serve(PostHandler, http.MethodGet, "/posts/2", "") // not cached
+	after := lookups()
+
+	want := [4]float64{2, 1, 1, 0}
+	for i := range want {
+		if got := after[i] - before[i]; got != want[i] {
+			t.Errorf("post hit/miss, list hit/miss moved by %v, want %v", [4]float64{after[0] - before[0], after[1] - before[1], after[2] - before[2], after[3] - before[3]}, want)
+			break
+		}
+	}
+}
+
+// disableCacheRoutes sets CACHE_DISABLED_ROUTES for the test and restores the
+// startup setting afterwards
+func disableCacheRoutes(t *testing.T, routes string) {
+	t.Helper()
+	t.Cleanup(cache.InitRouteCaching)
+	t.Setenv("CACHE_DISABLED_ROUTES", routes)
+	cache.InitRouteCaching()
+}
+
+func TestDisabledRoutesNeverTouchTheCache(t *testing.T) {
+	dbtest.Connect(t)
+	mr := cachetest.Start(t)
+	insertPost(t, models.Post{ID: 1, Body: "b", Version: 1})
+	disableCacheRoutes(t, "post, list")
+	lookups := func() float64 {
+		var n float64
+		for _, endpoint := range []string{metrics.EndpointPost, metrics.EndpointList} {
+			n += metrics.CacheLookups.Value(endpoint, "hit") + metrics.CacheLookups.Value(endpoint, "miss")
+		}
+		return n
+	}
+
+	before := lookups()
+	for _, target := range []string{"/posts/1", "/posts/1", "/posts?limit=5"} {
+		h := PostHandler
+		if target != "/posts/1" {
+			h = PostsHandler
+		}
+		if rec := serve(h, http.MethodGet, target, ""); rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", target, rec.Code, rec.Body)
+		}
+	}
+	if n := lookups() - before; n != 0 {
+		t.Errorf("disabled routes made %v cache lookups, want none", n)
+	}
+	// Reads still land in the caller's view history, which is not a cache
+	for _, key := range mr.Keys() {
+		if !strings.HasPrefix(key, "recent:") {
+			t.Errorf("disabled routes cached %q", key)
+		}
+	}
+}
+
+func TestDisablingOneRouteKeepsTheOther(t *testing.T) {
+	dbtest.Connect(t)
+	mr := cachetest.Start(t)
+	insertPost(t, models.Post{ID: 1, Body: "b", Version: 1})
+	disableCacheRoutes(t, "list")
+
+	serve(PostsHandler, http.MethodGet, "/posts?limit=5", "")
+	if keys := mr.Keys(); len(keys) != 0 {
+		t.Fatalf("disabled list route cached %v", keys)
+	}
+	serve(PostHandler, http.MethodGet, "/posts/1", "")
+	if !mr.Exists(cache.BuildPostKey(1)) {
+		t.Errorf("post route still enabled but %v cached", mr.Keys())
+	}
+}
+
+var registerCacheState sync.Once
+
+func scrapeCacheDisabled(t *testing.T) string {
+	t.Helper()
+	registerCacheState.Do(func() { metrics.RegisterCacheState(cache.Enabled) })
+	rec := httptest.NewRecorder()
+	metrics.Handler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if strings.HasPrefix(line, "cache_disabled ") {
+			return line
+		}
+	}
+	t.Fatalf("cache_disabled missing from /metrics:\n%s", rec.Body)
+	return ""
+}
+
+func TestNoCacheMarksReadsAndGauge(t *testing.T) {
+	cache.Use(nil)
+
+	for _, tt := range []struct {
+		h      http.HandlerFunc
+		target string
+	}{
+		{PostHandler, "/posts/1"},
+		{PostsHandler, "/posts"},
+	} {
+		// No database either, so the read fails, but the header still says why it was slow
+		rec := serve(tt.h, http.MethodGet, tt.target, "")
+		if got := rec.Header().Get("X-Cache"); got != cacheDisabled {
+			t.Errorf("GET %s: X-Cache = %q, want %q", tt.target, got, cacheDisabled)
+		}
+	}
+	if line := scrapeCacheDisabled(t); line != "cache_disabled 1" {
+		t.Errorf("got %q without Redis, want cache_disabled 1", line)
+	}
+
+	cachetest.Start(t)
+	if line := scrapeCacheDisabled(t); line != "cache_disabled 0" {
+		t.Errorf("got %q with Redis, want cache_disabled 0", line)
+	}
+}
+
+func createPost(t *testing.T, body string) models.Post {
+	t.Helper()
+	rec := serve(PostsHandler, http.MethodPost, "/posts", `{"body":"`+body+`"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", rec.Code, rec.Body)
+	}
+	var p models.Post
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPostIDsNeverReused(t *testing.T) {
+	dbtest.Connect(t)
+	seen := 0
+	for i := 0; i < 3; i++ {
+		if p := createPost(t, "post"); p.ID <= seen {
+			t.Fatalf("id %d after %d, want increasing ids", p.ID, seen)
+		} else {
+			seen = p.ID
+		}
+	}
+
+	// Delete the newest post, then purge its tombstone as well
+	top := strconv.Itoa(seen)
+	if rec := serve(PostHandler, http.MethodDelete, "/posts/"+top, ""); rec.Code >= 300 {
+		t.Fatalf("delete: status %d: %s", rec.Code, rec.Body)
+	}
+	if _, err := db.PostCol.DeleteOne(context.Background(), bson.M{"id": seen}); err != nil {
+		t.Fatal(err)
+	}
+
+	if p := createPost(t, "after delete"); p.ID <= seen {
+		t.Errorf("new post got id %d, want greater than every id seen (%d)", p.ID, seen)
+	}
+}
+
+var utcMillis = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z$`)
+
+func TestCreatedAtIsUTCWhateverTheServerTZ(t *testing.T) {
+	dbtest.Connect(t)
+	defer func(loc *time.Location) { time.Local = loc }(time.Local)
+	time.Local = time.FixedZone("UTC-7", -7*60*60)
+
+	rec := serve(PostsHandler, http.MethodPost, "/posts", `{"body":"b"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	createdAt, _ := raw["createdAt"].(string)
+	parsed, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil || !utcMillis.MatchString(createdAt) {
+		t.Fatalf("createdAt %q, want RFC3339 UTC with milliseconds", createdAt)
+	}
+	if d := time.Since(parsed); d < 0 || d > time.Minute {
+		t.Errorf("createdAt %s is %s from now, so the zone was misapplied", createdAt, d)
+	}
+}
+
+func TestPostTimestampsOnCreateAndEdit(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+
+	created := createPost(t, "first")
+	if created.CreatedAt.IsZero() || !created.UpdatedAt.Equal(created.CreatedAt) {
+		t.Fatalf("new post has createdAt %s and updatedAt %s, want both set and equal", created.CreatedAt, created.UpdatedAt)
+	}
+
+	// Timestamps have millisecond precision
+	time.Sleep(2 * time.Millisecond)
+	rec := serve(PostHandler, http.MethodPut, "/posts/"+strconv.Itoa(created.ID), `{"body":"edited"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("edit: status %d: %s", rec.Code, rec.Body)
+	}
+	var edited models.Post
+	if err := json.Unmarshal(rec.Body.Bytes(), &edited); err != nil {
+		t.Fatal(err)
+	}
+	if !edited.CreatedAt.Equal(created.CreatedAt) || !edited.UpdatedAt.After(edited.CreatedAt) {
+		t.Errorf("edited post has createdAt %s and updatedAt %s, want createdAt kept and updatedAt later", edited.CreatedAt, edited.UpdatedAt)
+	}
+
+	// Read twice so the second read comes from Redis
+	for _, source := range []string{"database", "cache"} {
+		rec := serve(PostHandler, http.MethodGet, "/posts/"+strconv.Itoa(created.ID)+"?meta=false", "")
+		var got models.Post
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !got.CreatedAt.Equal(edited.CreatedAt) || !got.UpdatedAt.Equal(edited.UpdatedAt) {
+			t.Errorf("%s read has createdAt %s and updatedAt %s, want %s and %s", source, got.CreatedAt, got.UpdatedAt, edited.CreatedAt, edited.UpdatedAt)
+		}
+	}
+}
+
+func TestListSortsByCreatedAt(t *testing.T) {
+	dbtest.Connect(t)
+	for range 3 {
+		createPost(t, "post")
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	if ids, _ := listIDs(t, "sort=createdAt"); !slices.Equal(ids, []int{3, 2, 1}) {
+		t.Errorf("sort=createdAt gave %v, want newest first", ids)
+	}
+	if ids, _ := listIDs(t, "sort=createdAt&order=asc"); !slices.Equal(ids, []int{1, 2, 3}) {
+		t.Errorf("sort=createdAt&order=asc gave %v, want oldest first", ids)
+	}
+}
+
+func TestGetPostMetaModes(t *testing.T) {
+	cachetest.Start(t)
+	created := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	cache.CachePost(models.Post{ID: 7, Body: "text", CreatedAt: created, UpdatedAt: created, Version: 1})
+
+	rec := serve(PostHandler, http.MethodGet, "/posts/7", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body, err)
+	}
+	if string(envelope["source"]) != `"cache"` || envelope["responseTimeMs"] == nil {
+		t.Errorf("default response is missing the meta envelope: %s", rec.Body)
+	}
+
+	rec = serve(PostHandler, http.MethodGet, "/posts/7?meta=false", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("meta=false status %d: %s", rec.Code, rec.Body)
+	}
+	var bare map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &bare); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body, err)
+	}
+	if _, ok := bare["source"]; ok {
+		t.Errorf("meta=false still has source: %s", rec.Body)
+	}
+	if string(bare["id"]) != "7" || string(bare["body"]) != `"text"` {
+		t.Errorf("meta=false body is not the bare post: %s", rec.Body)
+	}
+	if rec.Header().Get("X-Cache") != "HIT" || rec.Header().Get("X-Response-Time-Ms") == "" {
+		t.Errorf("meta=false headers X-Cache=%q X-Response-Time-Ms=%q",
+			rec.Header().Get("X-Cache"), rec.Header().Get("X-Response-Time-Ms"))
+	}
+
+	if rec := serve(PostHandler, http.MethodGet, "/posts/7?meta=maybe", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("meta=maybe status %d, want 400", rec.Code)
+	}
+}
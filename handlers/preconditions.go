@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"go-server/db"
+	"go-server/utils"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// unmodifiedSinceFilter narrows a write filter so it only matches when the
+// post hasn't changed after the request's If-Unmodified-Since date. The check
+// runs inside the write itself, so it can't race with another update.
+// A missing or malformed header leaves the filter untouched, as RFC 9110 asks.
+func unmodifiedSinceFilter(r *http.Request, filter bson.M) bson.M {
+	raw := r.Header.Get("If-Unmodified-Since")
+	if raw == "" {
+		return filter
+	}
+	since, err := http.ParseTime(raw)
+	if err != nil {
+		return filter
+	}
+
+	// HTTP dates only carry whole seconds, so anything within that second counts
+	cutoff := since.Add(time.Second)
+	filter["$or"] = bson.A{
+		bson.M{"updated_at": bson.M{"$lt": cutoff}},
+		bson.M{"updated_at": bson.M{"$exists": false}},
+	}
+	return filter
+}
+
+// respondNotFoundOrPreconditionFailed explains why a conditional write matched
+// nothing: either the post is gone, or it was modified after the client's date
+func respondNotFoundOrPreconditionFailed(ctx context.Context, w http.ResponseWriter, r *http.Request, id int) {
+	if preconditionFailed(ctx, r, id) {
+		utils.Error(w, "Post was modified after If-Unmodified-Since", http.StatusPreconditionFailed)
+		return
+	}
+	utils.RespondWithError(w, http.StatusNotFound, utils.CodePostNotFound, "Post not found")
+}
+
+// preconditionFailed reports whether a write that matched nothing missed
+// because of If-Unmodified-Since rather than because the post is gone
+func preconditionFailed(ctx context.Context, r *http.Request, id int) bool {
+	if r.Header.Get("If-Unmodified-Since") == "" {
+		return false
+	}
+	count, err := db.Count(ctx, db.Active(bson.M{"id": id}))
+	return err == nil && count > 0
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUnmodifiedSinceFilter(t *testing.T) {
+	for _, raw := range []string{"", "yesterday"} {
+		req := httptest.NewRequest(http.MethodPut, "/posts/1", nil)
+		if raw != "" {
+			req.Header.Set("If-Unmodified-Since", raw)
+		}
+		if filter := unmodifiedSinceFilter(req, bson.M{"id": 1}); len(filter) != 1 {
+			t.Errorf("If-Unmodified-Since %q changed the filter: %v", raw, filter)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/posts/1", nil)
+	req.Header.Set("If-Unmodified-Since", "Mon, 01 Jan 2024 10:00:00 GMT")
+	filter := unmodifiedSinceFilter(req, bson.M{"id": 1})
+	cond, ok := filter["$or"].(bson.A)
+	if !ok || len(cond) != 2 {
+		t.Fatalf("filter %v, want an $or on updated_at", filter)
+	}
+	// The whole second named by the date still counts as unmodified
+	want := time.Date(2024, 1, 1, 10, 0, 1, 0, time.UTC)
+	if got := cond[0].(bson.M)["updated_at"].(bson.M)["$lt"].(time.Time); !got.Equal(want) {
+		t.Errorf("cutoff %s, want %s", got, want)
+	}
+}
+
+func TestWritesHonorIfUnmodifiedSince(t *testing.T) {
+	dbtest.Connect(t)
+	stored := insertPost(t, models.Post{ID: 1, Body: "first", Version: 1})
+	before := stored.UpdatedAt.Add(-time.Hour).Format(http.TimeFormat)
+	after := stored.UpdatedAt.Format(http.TimeFormat)
+
+	if rec := serve(PostHandler, http.MethodPut, "/posts/1", `{"body":"second"}`, "If-Unmodified-Since", before); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT modified since: status %d, want 412: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(PostHandler, http.MethodDelete, "/posts/1", "", "If-Unmodified-Since", before); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("DELETE modified since: status %d, want 412: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(PostHandler, http.MethodPut, "/posts/1", `{"body":"second"}`, "If-Unmodified-Since", after); rec.Code != http.StatusOK {
+		t.Fatalf("PUT unmodified since: status %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	// The PUT moved updated_at on, so a date from a second before it fails again
+	var edited models.Post
+	if err := db.FindOne(context.Background(), bson.M{"id": 1}, &edited); err != nil {
+		t.Fatal(err)
+	}
+	stale := edited.UpdatedAt.Add(-time.Second).Format(http.TimeFormat)
+	if rec := serve(PostHandler, http.MethodDelete, "/posts/1", "", "If-Unmodified-Since", stale); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("DELETE after the edit: status %d, want 412: %s", rec.Code, rec.Body)
+	}
+	if rec := serve(PostHandler, http.MethodDelete, "/posts/1", "", "If-Unmodified-Since", edited.UpdatedAt.Format(http.TimeFormat)); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE unmodified since: status %d, want 204: %s", rec.Code, rec.Body)
+	}
+}
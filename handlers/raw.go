@@ -0,0 +1,15 @@
+package handlers
+
+import "net/http"
+
+// handleGetPostRaw serves only the post body as plain text, for embedding
+func handleGetPostRaw(w http.ResponseWriter, r *http.Request, id int) {
+	p, err := fetchPost(r.Context(), id)
+	if err != nil {
+		respondFetchError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(p.Body))
+}
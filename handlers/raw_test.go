@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"go-server/cache"
+	"go-server/cache/cachetest"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"testing"
+)
+
+func TestRawReturnsPlainBody(t *testing.T) {
+	dbtest.Connect(t)
+	insertPost(t, models.Post{ID: 1, Body: "# Title\n\n<b>not html</b>", Version: 1})
+
+	rec := serve(PostHandler, http.MethodGet, "/posts/1/raw", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/plain; charset=utf-8", got)
+	}
+	if got := rec.Body.String(); got != "# Title\n\n<b>not html</b>" {
+		t.Errorf("body = %q, want the post body alone", got)
+	}
+
+	if rec := serve(PostHandler, http.MethodGet, "/posts/2/raw", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("missing post: status %d, want 404", rec.Code)
+	}
+}
+
+func TestRawServedFromCache(t *testing.T) {
+	// No database: only the cached copy can answer
+	cachetest.Start(t)
+	cache.CachePost(models.Post{ID: 7, Body: "cached body", Version: 1})
+
+	rec := serve(PostHandler, http.MethodGet, "/posts/7/raw", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "cached body" {
+		t.Errorf("got %d %q, want 200 with the cached body", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go-server/cache"
+	"log"
+	"os"
+)
+
+const (
+	// ReadModeCacheFirst serves cache hits as-is and only reads MongoDB on a miss
+	ReadModeCacheFirst = "cache-first"
+	// ReadModeCacheFirstAsync serves cache hits immediately and refreshes the
+	// entry from MongoDB in the background, trading a little staleness for latency
+	ReadModeCacheFirstAsync = "cache-first-async"
+)
+
+var readMode = ReadModeCacheFirst
+
+// InitReadMode reads READ_MODE for single-post lookups
+func InitReadMode() {
+	raw := os.Getenv("READ_MODE")
+	switch raw {
+	case "":
+		return
+	case ReadModeCacheFirst, ReadModeCacheFirstAsync:
+		readMode = raw
+	default:
+		log.Fatalf("Invalid READ_MODE %q: must be %s or %s", raw, ReadModeCacheFirst, ReadModeCacheFirstAsync)
+	}
+	log.Printf("Read mode: %s", readMode)
+}
+
+// refreshPostAsync re-reads a post from MongoDB without blocking the caller.
+// loadPost recaches it on success; a post that's gone from the database is
+// evicted so the next weak read doesn't keep serving it.
+func refreshPostAsync(id int) {
+	go func() {
+		if _, err := loadPost(context.Background(), id); err != nil {
+			if errors.Is(err, errPostNotFound) {
+				cache.InvalidatePostCache(id)
+				return
+			}
+			log.Printf("Background refresh of post %d failed: %v", id, err)
+		}
+	}()
+}
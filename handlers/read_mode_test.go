@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/cache"
+	"go-server/cache/cachetest"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func useReadMode(t *testing.T, mode string) {
+	t.Helper()
+	old := readMode
+	readMode = mode
+	t.Cleanup(func() { readMode = old })
+}
+
+// waitForCache polls the post's cache entry until done accepts it
+func waitForCache(t *testing.T, id int, done func(models.Post, bool) bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		post, _, found := cache.GetCachedPost(id)
+		if done(post, found) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cache entry for post %d not refreshed: %+v (found %v)", id, post, found)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAsyncReadServesCacheThenRefreshes(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+	useReadMode(t, ReadModeCacheFirstAsync)
+	stored := insertPost(t, models.Post{ID: 1, Body: "fresh", Version: 2})
+	stale := stored
+	stale.Body, stale.Version = "stale", 1
+	cache.CachePost(stale)
+
+	rec := serve(PostHandler, http.MethodGet, "/posts/1?meta=false", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var got models.Post
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Body != "stale" {
+		t.Errorf("served %q, want the cached copy straight away", got.Body)
+	}
+	if h := rec.Header().Get("X-Read-Consistency"); h != "weak" {
+		t.Errorf("X-Read-Consistency = %q, want weak", h)
+	}
+
+	waitForCache(t, 1, func(p models.Post, found bool) bool { return found && p.Body == "fresh" })
+}
+
+func TestAsyncReadEvictsDeletedPost(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+	useReadMode(t, ReadModeCacheFirstAsync)
+	cache.CachePost(models.Post{ID: 1, Body: "gone", Version: 1})
+
+	if rec := serve(PostHandler, http.MethodGet, "/posts/1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	waitForCache(t, 1, func(_ models.Post, found bool) bool { return !found })
+}
+
+func TestCacheFirstReadIsNotMarkedWeak(t *testing.T) {
+	cachetest.Start(t)
+	useReadMode(t, ReadModeCacheFirst)
+	cache.CachePost(models.Post{ID: 1, Body: "b", Version: 1})
+
+	rec := serve(PostHandler, http.MethodGet, "/posts/1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if h := rec.Header().Get("X-Read-Consistency"); h != "" {
+		t.Errorf("X-Read-Consistency = %q on a cache-first read", h)
+	}
+}
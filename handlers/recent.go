@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"go-server/cache"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type RecentPostsResponse struct {
+	Posts []models.Post `json:"posts"`
+}
+
+// viewerKey identifies the client whose reads are tracked. There are no API
+// keys, so it is the remote IP.
+func viewerKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// recordView adds a successful read to the caller's history. Failures only
+// cost the history entry, so they're logged rather than surfaced.
+func recordView(r *http.Request, id int) {
+	if err := cache.RecordView(viewerKey(r), id); err != nil {
+		log.Printf("Error recording view of post %d: %v", id, err)
+	}
+}
+
+// Handling function for /posts/recent endpoint
+// The posts the caller has read most recently, newest first, for "continue
+// reading" features. Posts deleted since they were read are left out.
+func RecentPostsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !cache.Enabled() {
+		utils.RespondWithError(w, http.StatusServiceUnavailable, utils.CodeCacheUnavailable, "Cache unavailable")
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	ids, err := cache.RecentViews(viewerKey(r))
+	if err != nil {
+		log.Printf("Error reading recent views: %v", err)
+		utils.Error(w, "Error reading recent views", http.StatusInternalServerError)
+		return
+	}
+	resp := RecentPostsResponse{Posts: []models.Post{}}
+	if len(ids) == 0 {
+		utils.RespondWithJSON(w, resp)
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+	posts, err := db.FindPosts(ctx, db.Active(bson.M{"id": bson.M{"$in": ids}}), nil)
+	if err != nil {
+		log.Printf("Error fetching recent posts: %v", err)
+		utils.Error(w, "Error fetching posts", http.StatusInternalServerError)
+		return
+	}
+
+	byID := make(map[int]models.Post, len(posts))
+	for _, p := range posts {
+		byID[p.ID] = p
+	}
+	for _, id := range ids {
+		if p, found := byID[id]; found {
+			resp.Posts = append(resp.Posts, p)
+		}
+	}
+	utils.RespondWithJSON(w, resp)
+}
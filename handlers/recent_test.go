@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/cache/cachetest"
+	"go-server/db/dbtest"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestRecentPostsOrder(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+	for range 4 {
+		createPost(t, "post")
+	}
+
+	// httptest requests come from 192.0.2.1
+	for _, id := range []int{1, 2, 3, 1} {
+		if rec := serve(PostHandler, http.MethodGet, "/posts/"+strconv.Itoa(id), ""); rec.Code != http.StatusOK {
+			t.Fatalf("GET %d: status %d", id, rec.Code)
+		}
+	}
+	other := httptest.NewRequest(http.MethodGet, "/posts/4", nil)
+	other.RemoteAddr = "198.51.100.7:4000"
+	PostHandler(httptest.NewRecorder(), other)
+
+	if rec := serve(PostHandler, http.MethodDelete, "/posts/2", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status %d: %s", rec.Code, rec.Body)
+	}
+
+	rec := serve(RecentPostsHandler, http.MethodGet, "/posts/recent", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp RecentPostsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, p := range resp.Posts {
+		ids = append(ids, p.ID)
+	}
+	// Most recent first, re-reads move to the front, deleted and other
+	// clients' posts are left out
+	if want := []int{1, 3}; !slices.Equal(ids, want) {
+		t.Errorf("recent ids %v, want %v", ids, want)
+	}
+}
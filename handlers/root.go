@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"go-server/utils"
+	"log"
+	"net/http"
+	"os"
+)
+
+const (
+	RootModeDescriptor = "descriptor"
+	RootModeStatus     = "status"
+)
+
+// Version is stamped at build time with -ldflags "-X go-server/handlers.Version=..."
+var Version = "dev"
+
+var rootMode = RootModeDescriptor
+
+type ServiceDescriptor struct {
+	Service   string   `json:"service"`
+	Version   string   `json:"version"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// InitRootMode reads ROOT_MODE: descriptor (default) describes the service,
+// status just answers {"status":"ok"}
+func InitRootMode() {
+	raw := os.Getenv("ROOT_MODE")
+	switch raw {
+	case "":
+	case RootModeDescriptor, RootModeStatus:
+		rootMode = raw
+	default:
+		log.Fatalf("Invalid ROOT_MODE %q: must be %s or %s", raw, RootModeDescriptor, RootModeStatus)
+	}
+}
+
+// RootHandler answers GET / with a landing response listing endpoints.
+// Every other unmatched path still gets a 404.
+func RootHandler(endpoints []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			utils.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		if r.Method != http.MethodGet {
+			utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if rootMode == RootModeStatus {
+			utils.RespondWithJSON(w, map[string]string{"status": "ok"})
+			return
+		}
+		utils.RespondWithJSON(w, ServiceDescriptor{Service: "go-server", Version: Version, Endpoints: endpoints})
+	}
+}
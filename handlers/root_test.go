@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestRootDescriptor(t *testing.T) {
+	defer func(mode, version string) { rootMode, Version = mode, version }(rootMode, Version)
+	rootMode, Version = RootModeDescriptor, "1.2.3"
+	endpoints := []string{"/posts", "/healthz"}
+
+	rec := serve(RootHandler(endpoints), http.MethodGet, "/", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var desc ServiceDescriptor
+	if err := json.Unmarshal(rec.Body.Bytes(), &desc); err != nil {
+		t.Fatal(err)
+	}
+	if desc.Service != "go-server" || desc.Version != "1.2.3" || !slices.Equal(desc.Endpoints, endpoints) {
+		t.Errorf("descriptor %+v", desc)
+	}
+}
+
+func TestRootStatusMode(t *testing.T) {
+	defer func(mode string) { rootMode = mode }(rootMode)
+	rootMode = RootModeStatus
+
+	rec := serve(RootHandler(nil), http.MethodGet, "/", "")
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK || len(body) != 1 || body["status"] != "ok" {
+		t.Errorf("status %d: %s, want only status ok", rec.Code, rec.Body)
+	}
+}
+
+func TestRootOnlyAnswersRoot(t *testing.T) {
+	h := RootHandler(nil)
+	if rec := serve(h, http.MethodGet, "/nope", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown path: status %d, want 404", rec.Code)
+	}
+	if rec := serve(h, http.MethodPost, "/", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /: status %d, want 405", rec.Code)
+	}
+}
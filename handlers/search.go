@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// errCodeIndexNotFound is what MongoDB answers a $text query with when the
+// collection has no text index
+const errCodeIndexNotFound = 27
+
+// SearchResult is a post with its text-search relevance. Score is absent when
+// the search fell back to substring matching.
+type SearchResult struct {
+	models.Post `bson:",inline"`
+	Score       float64 `json:"score,omitempty" bson:"score"`
+}
+
+func (r SearchResult) MarshalJSON() ([]byte, error) {
+	return models.MarshalWithFields(r.Post, struct {
+		Score float64 `json:"score,omitempty"`
+	}{r.Score})
+}
+
+// Handling function for /posts/search endpoint
+// Full-text search over post bodies, most relevant first
+func SearchPostsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	handleSearchPosts(w, r)
+}
+
+// handleSearchPosts serves /posts/search and GET /posts?q=
+func handleSearchPosts(w http.ResponseWriter, r *http.Request) {
+	term := strings.TrimSpace(utils.QueryParam(r, "q"))
+	if term == "" {
+		utils.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	// minScore drops weak matches; textScore is never negative
+	var minScore float64
+	if raw := utils.QueryParam(r, "minScore"); raw != "" {
+		var err error
+		if minScore, err = strconv.ParseFloat(raw, 64); err != nil || minScore < 0 || math.IsNaN(minScore) || math.IsInf(minScore, 0) {
+			utils.Error(w, "minScore must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+	}
+	limit, offset := utils.ParsePaginationParams(r)
+
+	if !requireDB(w) {
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	// Relevance isn't available to a plain find filter, so the threshold,
+	// page and total all come out of one aggregation
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: db.Active(bson.M{"$text": bson.M{"$search": term}})}},
+		{{Key: "$addFields", Value: bson.M{"score": bson.M{"$meta": "textScore"}}}},
+		{{Key: "$match", Value: bson.M{"score": bson.M{"$gte": minScore}}}},
+		{{Key: "$facet", Value: bson.M{
+			"posts": bson.A{
+				bson.M{"$sort": bson.D{{Key: "score", Value: -1}, {Key: "id", Value: 1}}},
+				bson.M{"$skip": offset},
+				bson.M{"$limit": limit},
+			},
+			"total": bson.A{bson.M{"$count": "n"}},
+		}}},
+	}
+
+	var out []struct {
+		Posts []SearchResult `bson:"posts"`
+		Total []struct {
+			N int64 `bson:"n"`
+		} `bson:"total"`
+	}
+	err := db.Aggregate(ctx, pipeline, &out)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.HasErrorCode(errCodeIndexNotFound) {
+		log.Printf("Text index missing, searching posts by substring; POST /admin/reindex restores it")
+		searchPostsByRegex(ctx, w, term, limit, offset)
+		return
+	}
+	if err != nil {
+		log.Printf("Error searching posts: %v", err)
+		utils.Error(w, "Error searching posts", http.StatusInternalServerError)
+		return
+	}
+
+	results, total := []SearchResult{}, int64(0)
+	if len(out) > 0 {
+		if out[0].Posts != nil {
+			results = out[0].Posts
+		}
+		if len(out[0].Total) > 0 {
+			total = out[0].Total[0].N
+		}
+	}
+	utils.RespondWithJSON(w, PaginatedResponse{Posts: results, TotalPosts: total, Limit: limit, Offset: offset})
+}
+
+// searchPostsByRegex is the fallback for when the text index is missing: a
+// case-insensitive substring match on the body, in id order. It can't rank
+// results, so minScore doesn't apply and X-Search-Mode tells clients so.
+func searchPostsByRegex(ctx context.Context, w http.ResponseWriter, term string, limit, offset int) {
+	filter := db.Active(bson.M{"body": bson.M{"$regex": regexp.QuoteMeta(term), "$options": "i"}})
+
+	total, err := db.Count(ctx, filter)
+	if err != nil {
+		log.Printf("Error counting search results: %v", err)
+		utils.Error(w, "Error searching posts", http.StatusInternalServerError)
+		return
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "id", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	posts, err := db.FindPosts(ctx, filter, opts)
+	if err != nil {
+		log.Printf("Error searching posts: %v", err)
+		utils.Error(w, "Error searching posts", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]SearchResult, len(posts))
+	for i, p := range posts {
+		results[i].Post = p
+	}
+	w.Header().Set("X-Search-Mode", "regex")
+	utils.RespondWithJSON(w, PaginatedResponse{Posts: results, TotalPosts: total, Limit: limit, Offset: offset})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"go-server/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type searchPage struct {
+	Posts      []SearchResult `json:"posts"`
+	TotalPosts int64          `json:"totalPosts"`
+	Limit      int            `json:"limit"`
+}
+
+func search(t *testing.T, query string) searchPage {
+	t.Helper()
+	rec := serve(SearchPostsHandler, http.MethodGet, "/posts/search?"+query, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%q: status %d: %s", query, rec.Code, rec.Body)
+	}
+	var page searchPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
+
+func TestSearchCapsLimit(t *testing.T) {
+	dbtest.Connect(t)
+	t.Cleanup(func() { utils.LoadPageLimits() })
+	t.Setenv("MAX_PAGE_LIMIT", "10")
+	if err := utils.LoadPageLimits(); err != nil {
+		t.Fatal(err)
+	}
+	for id := 1; id <= 15; id++ {
+		insertPost(t, models.Post{ID: id, Body: "redis notes", Version: 1})
+	}
+
+	page := search(t, "q=redis&limit=1000")
+	if len(page.Posts) != 10 || page.Limit != 10 || page.TotalPosts != 15 {
+		t.Errorf("got %d posts, limit %d, total %d; want 10, 10, 15", len(page.Posts), page.Limit, page.TotalPosts)
+	}
+}
+
+func TestSearchFiltersByScore(t *testing.T) {
+	dbtest.Connect(t)
+	insertPost(t, models.Post{ID: 1, Body: "redis redis redis", Version: 1})
+	insertPost(t, models.Post{ID: 2, Body: "redis " + strings.Repeat("and a lot of other words ", 10), Version: 1})
+	insertPost(t, models.Post{ID: 3, Body: "nothing relevant", Version: 1})
+
+	all := search(t, "q=redis")
+	if len(all.Posts) != 2 || all.Posts[0].ID != 1 || all.Posts[0].Score <= all.Posts[1].Score {
+		t.Fatalf("unfiltered results %+v, want post 1 ranked above post 2", all.Posts)
+	}
+
+	threshold := (all.Posts[0].Score + all.Posts[1].Score) / 2
+	filtered := search(t, "q=redis&minScore="+strconv.FormatFloat(threshold, 'f', -1, 64))
+	if len(filtered.Posts) != 1 || filtered.Posts[0].ID != 1 || filtered.TotalPosts != 1 {
+		t.Errorf("minScore %v kept %+v (total %d), want only post 1", threshold, filtered.Posts, filtered.TotalPosts)
+	}
+}
+
+func TestSearchValidatesMinScore(t *testing.T) {
+	for _, raw := range []string{"-1", "high", "NaN", "Inf"} {
+		rec := serve(SearchPostsHandler, http.MethodGet, "/posts/search?q=redis&minScore="+raw, "")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("minScore=%s: status %d, want 400", raw, rec.Code)
+		}
+	}
+}
+
+func TestListWithQSearchesBodies(t *testing.T) {
+	dbtest.Connect(t)
+	for id, body := range map[int]string{1: "redis caching", 2: "mongo indexes", 3: "redis redis cluster", 4: "unrelated"} {
+		insertPost(t, models.Post{ID: id, Body: body, Version: 1})
+	}
+
+	rec := serve(PostsHandler, http.MethodGet, "/posts?q=redis", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var page searchPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalPosts != 2 || len(page.Posts) != 2 {
+		t.Fatalf("got %+v, want the two redis posts", page)
+	}
+	// The body that repeats the term ranks first
+	if page.Posts[0].ID != 3 || page.Posts[1].ID != 1 {
+		t.Errorf("order %d, %d; want 3, 1", page.Posts[0].ID, page.Posts[1].ID)
+	}
+}
+
+func TestSearchFallsBackWithoutTextIndex(t *testing.T) {
+	dbtest.Connect(t)
+	for id, body := range map[int]string{1: "Redis caching", 2: "mongo indexes", 3: "about REDIS"} {
+		insertPost(t, models.Post{ID: id, Body: body, Version: 1})
+	}
+	if _, err := db.PostCol.Indexes().DropOne(context.Background(), "body_text"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := serve(PostsHandler, http.MethodGet, "/posts?q=redis", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("X-Search-Mode"); got != "regex" {
+		t.Errorf("X-Search-Mode = %q, want regex", got)
+	}
+	var page searchPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalPosts != 2 || len(page.Posts) != 2 || page.Posts[0].ID != 1 || page.Posts[1].ID != 3 {
+		t.Errorf("got %+v, want posts 1 and 3 in id order", page)
+	}
+}
+
+func TestSearchResultJSONKeepsScore(t *testing.T) {
+	data, err := json.Marshal(SearchResult{Post: models.Post{ID: 1, Body: "redis"}, Score: 0.75})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["score"] != 0.75 || got["id"] != 1.0 || got["body"] != "redis" {
+		t.Errorf("got %s, want the post fields and score", data)
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go-server/cache"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+var serveStaleOnError bool
+
+// InitStaleFallback reads SERVE_STALE_ON_ERROR. When enabled every post read
+// from MongoDB is also kept as a long-lived last-known-good copy, which is
+// served if a later read fails.
+func InitStaleFallback() {
+	raw := os.Getenv("SERVE_STALE_ON_ERROR")
+	if raw == "" {
+		return
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Fatalf("Invalid SERVE_STALE_ON_ERROR %q: must be true or false", raw)
+	}
+	serveStaleOnError = enabled
+}
+
+// serveStale answers with the last-known-good copy of a post when the
+// database read failed for a reason other than the post not existing
+func serveStale(ctx context.Context, w http.ResponseWriter, id int, start time.Time, meta bool, loadErr error) bool {
+	if !serveStaleOnError || errors.Is(loadErr, errPostNotFound) {
+		return false
+	}
+	post, age, found := cache.GetStalePost(id)
+	if !found {
+		cache.Trace(ctx, "stale", "miss")
+		return false
+	}
+	cache.Trace(ctx, "stale", "hit")
+
+	log.Printf("Serving stale copy of post %d after read error: %v", id, loadErr)
+	w.Header().Set("Warning", `110 - "Response is Stale"`)
+	w.Header().Set("X-Cache-Age", strconv.Itoa(int(age.Seconds())))
+	respondPost(w, post, "stale", start, true, meta)
+	return true
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/cache"
+	"go-server/cache/cachetest"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"testing"
+)
+
+func useStaleFallback(t *testing.T, enabled bool) {
+	t.Helper()
+	old := serveStaleOnError
+	serveStaleOnError = enabled
+	t.Cleanup(func() { serveStaleOnError = old })
+}
+
+func TestStaleCopyServedWhenDBFails(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+	useStaleFallback(t, true)
+	insertPost(t, models.Post{ID: 1, Body: "last known good", Version: 1})
+
+	// A successful read keeps the last-known-good copy
+	if rec := serve(PostHandler, http.MethodGet, "/posts/1", ""); rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	// Then the cache entry expires and MongoDB goes away
+	cache.InvalidatePostCache(1)
+	col := db.PostCol
+	db.PostCol = nil
+	defer func() { db.PostCol = col }()
+
+	rec := serve(PostHandler, http.MethodGet, "/posts/1?meta=false", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want the stale copy: %s", rec.Code, rec.Body)
+	}
+	if w := rec.Header().Get("Warning"); w != `110 - "Response is Stale"` {
+		t.Errorf("Warning = %q, want 110", w)
+	}
+	var p models.Post
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Body != "last known good" {
+		t.Errorf("served %q, want the stale copy", p.Body)
+	}
+}
+
+func TestStaleFallbackOff(t *testing.T) {
+	cachetest.Start(t)
+	useStaleFallback(t, false)
+	cache.CacheStalePost(models.Post{ID: 1, Body: "old", Version: 1})
+
+	rec := serve(PostHandler, http.MethodGet, "/posts/1", "")
+	if rec.Code == http.StatusOK || rec.Header().Get("Warning") != "" {
+		t.Errorf("status %d, Warning %q: stale copy served with SERVE_STALE_ON_ERROR off", rec.Code, rec.Header().Get("Warning"))
+	}
+}
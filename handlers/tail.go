@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-server/db"
+	"go-server/events"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultTailBackfill = 10
+	// tailBuffer is how many created posts a tail may fall behind by before
+	// it is disconnected; the client can reconnect and backfill again
+	tailBuffer    = 64
+	tailHeartbeat = 15 * time.Second
+)
+
+// tails fans post.created events out to every open /posts/tail stream.
+// closing is closed once the server starts shutting down.
+var tails = struct {
+	sync.Mutex
+	once    sync.Once
+	subs    map[chan models.Post]struct{}
+	closing chan struct{}
+}{subs: make(map[chan models.Post]struct{}), closing: make(chan struct{})}
+
+// CloseTailStreams ends every open /posts/tail stream and any opened later.
+// http.Server.Shutdown waits for handlers without cancelling their requests,
+// so main registers this with RegisterOnShutdown to stop streams holding the
+// drain open until the shutdown timeout.
+func CloseTailStreams() {
+	tails.Lock()
+	defer tails.Unlock()
+	select {
+	case <-tails.closing:
+	default:
+		close(tails.closing)
+	}
+}
+
+func tailsClosing() <-chan struct{} {
+	tails.Lock()
+	defer tails.Unlock()
+	return tails.closing
+}
+
+func subscribeTail() chan models.Post {
+	tails.once.Do(func() {
+		events.Subscribe(func(e events.Event) {
+			tails.Lock()
+			defer tails.Unlock()
+			for ch := range tails.subs {
+				select {
+				case ch <- e.Post:
+				default:
+					// Closing tells the stream it missed a post
+					delete(tails.subs, ch)
+					close(ch)
+				}
+			}
+		}, events.PostCreated)
+	})
+
+	ch := make(chan models.Post, tailBuffer)
+	tails.Lock()
+	tails.subs[ch] = struct{}{}
+	tails.Unlock()
+	return ch
+}
+
+func unsubscribeTail(ch chan models.Post) {
+	tails.Lock()
+	defer tails.Unlock()
+	if _, ok := tails.subs[ch]; ok {
+		delete(tails.subs, ch)
+		close(ch)
+	}
+}
+
+// Handling function for /posts/tail endpoint
+// Sends the last ?last= posts, oldest first, then streams newly created posts
+// as server-sent events on the same connection
+func PostsTailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	last := defaultTailBackfill
+	if raw := utils.QueryParam(r, "last"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 || parsed > utils.MaxPageLimit() {
+			utils.Error(w, fmt.Sprintf("last must be between 0 and %d", utils.MaxPageLimit()), http.StatusBadRequest)
+			return
+		}
+		last = parsed
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	if !requireDB(w) {
+		return
+	}
+
+	// Subscribe before reading the backfill so nothing created in between is
+	// lost; anything the backfill already returned is skipped when it arrives
+	live := subscribeTail()
+	defer unsubscribeTail(live)
+
+	backfill, err := latestCreated(r, last)
+	if err != nil {
+		log.Printf("Error reading tail backfill: %v", err)
+		utils.Error(w, "Error fetching posts", http.StatusInternalServerError)
+		return
+	}
+	sent := make(map[int]bool, len(backfill))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for _, p := range backfill {
+		sent[p.ID] = true
+		writeTailEvent(w, p)
+	}
+	flusher.Flush()
+
+	closing := tailsClosing()
+	heartbeat := time.NewTicker(tailHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-closing:
+			fmt.Fprint(w, "event: close\ndata: server shutting down\n\n")
+			flusher.Flush()
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case p, open := <-live:
+			if !open {
+				fmt.Fprint(w, "event: error\ndata: client fell behind\n\n")
+				flusher.Flush()
+				return
+			}
+			if sent[p.ID] {
+				continue
+			}
+			writeTailEvent(w, p)
+			flusher.Flush()
+		}
+	}
+}
+
+// latestCreated returns the n most recently created posts, oldest first
+func latestCreated(r *http.Request, n int) ([]models.Post, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "id", Value: -1}}).SetLimit(int64(n))
+	posts, err := db.FindPosts(ctx, db.Active(bson.M{}), opts)
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(posts)
+	return posts, nil
+}
+
+func writeTailEvent(w http.ResponseWriter, p models.Post) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		log.Printf("Error encoding post %d for tail: %v", p.ID, err)
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: post\ndata: %s\n\n", p.ID, data)
+}
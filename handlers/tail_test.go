@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"go-server/db/dbtest"
+	"go-server/events"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readTailIDs reads n post events off an SSE stream and returns their ids
+func readTailIDs(t *testing.T, lines *bufio.Scanner, n int) []int {
+	t.Helper()
+	var ids []int
+	for len(ids) < n && lines.Scan() {
+		if raw, ok := strings.CutPrefix(lines.Text(), "id: "); ok {
+			id, err := strconv.Atoi(raw)
+			if err != nil {
+				t.Fatalf("bad event id %q", raw)
+			}
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < n {
+		t.Fatalf("stream ended after %v: %v", ids, lines.Err())
+	}
+	return ids
+}
+
+// openTail connects to a tail stream and returns its lines. The deadline
+// bounds later reads if an event never arrives.
+func openTail(t *testing.T, url string) *bufio.Scanner {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	return bufio.NewScanner(resp.Body)
+}
+
+func TestTailBackfillThenLive(t *testing.T) {
+	dbtest.Connect(t)
+	var posts []models.Post
+	for range 5 {
+		posts = append(posts, createPost(t, "post"))
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(PostsTailHandler))
+	t.Cleanup(srv.Close)
+	lines := openTail(t, srv.URL+"/posts/tail?last=3")
+
+	if got := readTailIDs(t, lines, 3); !slices.Equal(got, []int{3, 4, 5}) {
+		t.Fatalf("backfill ids %v, want [3 4 5]", got)
+	}
+
+	// A late event for a backfilled post must not be sent twice
+	events.Publish(events.Event{Type: events.PostCreated, PostID: 5, Post: posts[4]})
+	created := createPost(t, "live")
+
+	if got := readTailIDs(t, lines, 1); got[0] != created.ID {
+		t.Errorf("live id %d, want %d", got[0], created.ID)
+	}
+}
+
+func TestTailClosesOnShutdown(t *testing.T) {
+	dbtest.Connect(t)
+	t.Cleanup(func() {
+		tails.Lock()
+		tails.closing = make(chan struct{})
+		tails.Unlock()
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(PostsTailHandler))
+	t.Cleanup(srv.Close)
+	srv.Config.RegisterOnShutdown(CloseTailStreams)
+	lines := openTail(t, srv.URL+"/posts/tail?last=0")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := srv.Config.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown with an open tail stream: %v", err)
+	}
+	var names []string
+	for lines.Scan() {
+		if name, ok := strings.CutPrefix(lines.Text(), "event: "); ok {
+			names = append(names, name)
+		}
+	}
+	if !slices.Equal(names, []string{"close"}) {
+		t.Errorf("stream events %v, want [close]", names)
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"go-server/db"
+	"go-server/events"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// handleTouchPost bumps updated_at and version without changing content,
+// e.g. to bust client caches or mark a post as seen
+func handleTouchPost(w http.ResponseWriter, r *http.Request, id int) {
+	if !requireDB(w) {
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{"updated_at": models.Now()},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var touched models.Post
+	err := db.PostCol.FindOneAndUpdate(ctx, db.Active(bson.M{"id": id}), update, opts).Decode(&touched)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		utils.RespondWithError(w, http.StatusNotFound, utils.CodePostNotFound, "Post not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error touching post %d: %v", id, err)
+		utils.Error(w, "Error updating post", http.StatusInternalServerError)
+		return
+	}
+
+	events.Publish(events.Event{Type: events.PostUpdated, PostID: id, Post: touched})
+	utils.RespondWithJSON(w, touched)
+}
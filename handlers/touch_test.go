@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestTouchBumpsUpdatedAtOnly(t *testing.T) {
+	dbtest.Connect(t)
+	created := models.Now().Add(-time.Hour)
+	before := insertPost(t, models.Post{ID: 1, Body: "body", Tags: []string{"a", "b"}, Version: 3, CreatedAt: created, UpdatedAt: created})
+
+	rec := serve(PostHandler, http.MethodPost, "/posts/1/touch", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var touched models.Post
+	if err := json.Unmarshal(rec.Body.Bytes(), &touched); err != nil {
+		t.Fatal(err)
+	}
+	var stored models.Post
+	if err := db.FindOne(context.Background(), bson.M{"id": 1}, &stored); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, p := range map[string]models.Post{"response": touched, "stored": stored} {
+		if !p.UpdatedAt.After(before.UpdatedAt) {
+			t.Errorf("%s updatedAt %s, want after %s", name, p.UpdatedAt, before.UpdatedAt)
+		}
+		if p.Version != before.Version+1 {
+			t.Errorf("%s version %d, want %d", name, p.Version, before.Version+1)
+		}
+		if p.Body != before.Body || !slices.Equal(p.Tags, before.Tags) || !p.CreatedAt.Equal(before.CreatedAt) {
+			t.Errorf("%s content changed: %+v, was %+v", name, p, before)
+		}
+	}
+}
+
+func TestTouchMissingPost(t *testing.T) {
+	dbtest.Connect(t)
+	if rec := serve(PostHandler, http.MethodPost, "/posts/1/touch", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("status %d, want 404: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+const (
+	// EmptyUpdateReject answers an update with no fields with 400
+	EmptyUpdateReject = "reject"
+	// EmptyUpdateNoop answers it with the unchanged post, without bumping its version
+	EmptyUpdateNoop = "noop"
+)
+
+// A post only has a handful of editable fields, so anything past this is abuse
+const defaultMaxUpdateFields = 4
+
+var maxUpdateFields atomic.Int64
+
+// emptyUpdatePolicy decides what PUT /posts/{id} does with a `{}` body
+var emptyUpdatePolicy = EmptyUpdateReject
+
+func init() {
+	maxUpdateFields.Store(defaultMaxUpdateFields)
+}
+
+// InitUpdateLimits reads MAX_UPDATE_FIELDS, the most top-level keys a single
+// update body may carry
+func InitUpdateLimits() {
+	if err := LoadUpdateLimits(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// LoadUpdateLimits applies MAX_UPDATE_FIELDS, keeping the current limit on a
+// bad value
+func LoadUpdateLimits() error {
+	n := defaultMaxUpdateFields
+	if raw := os.Getenv("MAX_UPDATE_FIELDS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			return fmt.Errorf("Invalid MAX_UPDATE_FIELDS %q: must be a positive integer", raw)
+		}
+		n = parsed
+	}
+	maxUpdateFields.Store(int64(n))
+	return nil
+}
+
+// checkUpdateFieldCount rejects update bodies with more than maxUpdateFields
+// keys before they reach schema validation, which would otherwise report an
+// error per key
+func checkUpdateFieldCount(w http.ResponseWriter, body []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		// Not an object; leave the error to schema validation
+		return true
+	}
+	if limit := maxUpdateFields.Load(); int64(len(fields)) > limit {
+		utils.Error(w, fmt.Sprintf("Too many update fields: %d (max %d)", len(fields), limit), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// InitEmptyUpdatePolicy reads EMPTY_UPDATE, reject (default) or noop
+func InitEmptyUpdatePolicy() {
+	switch raw := os.Getenv("EMPTY_UPDATE"); raw {
+	case "", EmptyUpdateReject:
+		emptyUpdatePolicy = EmptyUpdateReject
+	case EmptyUpdateNoop:
+		emptyUpdatePolicy = EmptyUpdateNoop
+	default:
+		log.Fatalf("Invalid EMPTY_UPDATE %q: must be reject or noop", raw)
+	}
+}
+
+// isEmptyUpdate reports whether an update body is an object with no fields.
+// It would otherwise become a $set with nothing in it, which MongoDB rejects.
+func isEmptyUpdate(body []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		// Not an object; leave the error to schema validation
+		return false
+	}
+	return fields != nil && len(fields) == 0
+}
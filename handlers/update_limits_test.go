@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"go-server/db"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUpdateFieldCountLimit(t *testing.T) {
+	tests := []struct {
+		body string
+		ok   bool
+	}{
+		{`{"body":"x"}`, true},
+		{`{"body":"x","tags":[],"a":1,"b":2}`, true},
+		{`{"body":"x","tags":[],"a":1,"b":2,"c":3}`, false},
+		{`[1,2,3,4,5,6]`, true}, // not an object, left to schema validation
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		if ok := checkUpdateFieldCount(rec, []byte(tt.body)); ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.body, ok, tt.ok)
+		}
+		if !tt.ok && rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", tt.body, rec.Code)
+		}
+	}
+}
+
+func TestLoadUpdateLimitsKeepsLimitOnBadValue(t *testing.T) {
+	defer func(n int64) { maxUpdateFields.Store(n) }(maxUpdateFields.Load())
+
+	t.Setenv("MAX_UPDATE_FIELDS", "2")
+	if err := LoadUpdateLimits(); err != nil || maxUpdateFields.Load() != 2 {
+		t.Fatalf("limit %d, err %v; want 2", maxUpdateFields.Load(), err)
+	}
+	for _, raw := range []string{"0", "-1", "lots"} {
+		t.Setenv("MAX_UPDATE_FIELDS", raw)
+		if err := LoadUpdateLimits(); err == nil {
+			t.Errorf("%q accepted", raw)
+		}
+		if n := maxUpdateFields.Load(); n != 2 {
+			t.Errorf("%q changed the limit to %d", raw, n)
+		}
+	}
+}
+
+func TestEditRejectsTooManyFields(t *testing.T) {
+	dbtest.Connect(t)
+	insertPost(t, models.Post{ID: 1, Body: "b", Version: 1})
+
+	fields := make([]string, defaultMaxUpdateFields+1)
+	for i := range fields {
+		fields[i] = `"f` + strings.Repeat("x", i) + `":1`
+	}
+	rec := serve(PostHandler, http.MethodPut, "/posts/1", "{"+strings.Join(fields, ",")+"}")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Too many update fields") {
+		t.Fatalf("status %d: %s, want 400 for too many fields", rec.Code, rec.Body)
+	}
+
+	var stored models.Post
+	if err := db.FindOne(context.Background(), bson.M{"id": 1}, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Version != 1 {
+		t.Errorf("post written at version %d despite the rejection", stored.Version)
+	}
+}
+
+func TestEmptyUpdatePolicies(t *testing.T) {
+	dbtest.Connect(t)
+	insertPost(t, models.Post{ID: 1, Body: "b", Version: 1})
+	defer func() { emptyUpdatePolicy = EmptyUpdateReject }()
+
+	emptyUpdatePolicy = EmptyUpdateReject
+	rec := serve(PostHandler, http.MethodPut, "/posts/1", "{}")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "No fields to update") {
+		t.Errorf("reject: status %d: %s, want 400", rec.Code, rec.Body)
+	}
+
+	emptyUpdatePolicy = EmptyUpdateNoop
+	rec = serve(PostHandler, http.MethodPut, "/posts/1", "{}")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"body":"b"`) {
+		t.Errorf("noop: status %d: %s, want 200 with the post", rec.Code, rec.Body)
+	}
+	if rec := serve(PostHandler, http.MethodPut, "/posts/2", "{}"); rec.Code != http.StatusNotFound {
+		t.Errorf("noop on a missing post: status %d, want 404", rec.Code)
+	}
+
+	if p := storedPost(t, 1); p.Version != 1 || p.Body != "b" {
+		t.Errorf("empty updates changed the post: %+v", p)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"go-server/cache"
+	"go-server/db"
+	"go-server/models"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// handleCountView serves GET /posts/{id}?countView=true: it increments the
+// post's view count and returns the post with the new count in one
+// FindOneAndUpdate, so concurrent reads never lose an increment. It always
+// reads MongoDB, since a cached copy can't carry the new count.
+func handleCountView(w http.ResponseWriter, r *http.Request, id int, start time.Time, meta bool) {
+	if !requireDB(w) {
+		return
+	}
+
+	ctx, cancel := dbContext(r.Context())
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var p models.Post
+	err := db.PostCol.FindOneAndUpdate(ctx, db.Active(bson.M{"id": id}), bson.M{"$inc": bson.M{"views": 1}}, opts).Decode(&p)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		utils.RespondWithError(w, http.StatusNotFound, utils.CodePostNotFound, "Post not found")
+		return
+	}
+	if err != nil {
+		log.Printf("Error counting view of post %d: %v", id, err)
+		utils.Error(w, "Error fetching post", http.StatusInternalServerError)
+		return
+	}
+	cache.Trace(r.Context(), "db", "hit")
+
+	// Refresh the post's own entry so plain reads see the new count. List
+	// pages are left alone: dropping them on every view would defeat the
+	// list cache, so their counts may lag by up to CACHE_TTL.
+	if cache.EnabledFor(cache.RoutePost) {
+		cache.CachePost(p)
+		cache.Trace(r.Context(), "redis", "store")
+	}
+	recordView(r, id)
+	respondPost(w, p, "database", start, false, meta)
+}
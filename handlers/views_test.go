@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"go-server/cache"
+	"go-server/cache/cachetest"
+	"go-server/db/dbtest"
+	"go-server/models"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestCountViewConcurrent(t *testing.T) {
+	dbtest.Connect(t)
+	cachetest.Start(t)
+	insertPost(t, models.Post{ID: 1, Body: "post", Version: 1})
+
+	const reads = 50
+	counts := make([]int, reads)
+	var wg sync.WaitGroup
+	for i := range reads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := serve(PostHandler, http.MethodGet, "/posts/1?countView=true&meta=false", "")
+			if rec.Code != http.StatusOK {
+				t.Errorf("status %d: %s", rec.Code, rec.Body)
+				return
+			}
+			var p models.Post
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Error(err)
+				return
+			}
+			counts[i] = p.Views
+		}()
+	}
+	wg.Wait()
+
+	// Each read saw its own increment, so the counts are 1 to reads once each
+	slices.Sort(counts)
+	for i, n := range counts {
+		if n != i+1 {
+			t.Fatalf("view counts %v, want 1 to %d each once", counts, reads)
+		}
+	}
+	if p := storedPost(t, 1); p.Views != reads {
+		t.Errorf("stored views %d, want %d", p.Views, reads)
+	}
+	// Uncounted reads leave the count alone and see it through the cache
+	rec := serve(PostHandler, http.MethodGet, "/posts/1?meta=false", "")
+	var p models.Post
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Views != reads {
+		t.Errorf("plain read shows %d views, want %d", p.Views, reads)
+	}
+	if cached, _, found := cache.GetCachedPost(1); !found || cached.Views != reads {
+		t.Errorf("cached post %+v (found %v), want %d views", cached, found, reads)
+	}
+}
+
+func TestCountViewMissingPost(t *testing.T) {
+	dbtest.Connect(t)
+	if rec := serve(PostHandler, http.MethodGet, "/posts/9?countView=true", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("status %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go-server/cache"
+	"go-server/db"
+	"go-server/models"
+	"log"
+	"math/rand"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const warmupPostCount = 100
+
+// warmupWindow is how long the post-deploy warm-up is spread over; 0 disables it
+var warmupWindow time.Duration
+
+// InitCacheWarmup reads CACHE_WARMUP_WINDOW (e.g. "30s"). Unset or 0
+// disables the warm-up.
+func InitCacheWarmup() {
+	raw := os.Getenv("CACHE_WARMUP_WINDOW")
+	if raw == "" {
+		return
+	}
+	window, err := time.ParseDuration(raw)
+	if err != nil || window < 0 {
+		log.Fatalf("Invalid CACHE_WARMUP_WINDOW %q: must be a non-negative duration", raw)
+	}
+	warmupWindow = window
+}
+
+// StartCacheWarmup repopulates the cache in the background after a deploy.
+// Instead of letting the first burst of traffic miss on every key at once,
+// the most recently updated posts are cached at jittered times spread across
+// CACHE_WARMUP_WINDOW.
+func StartCacheWarmup() {
+	if warmupWindow == 0 || db.PostCol == nil || !cache.EnabledFor(cache.RoutePost) {
+		return
+	}
+	go warmCache(warmupWindow)
+}
+
+// warmupClock is the time source for the warm-up schedule, swapped out in tests
+type warmupClock struct {
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+var realClock = warmupClock{now: time.Now, sleep: time.Sleep}
+
+func warmCache(window time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	findOptions := options.Find().
+		SetLimit(warmupPostCount).
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetProjection(bson.M{"id": 1})
+
+	var posts []models.Post
+	if err := db.Find(ctx, db.Active(bson.M{}), findOptions, &posts); err != nil {
+		log.Printf("Cache warm-up skipped: %v", err)
+		return
+	}
+	ids := make([]int, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	warmPosts(ids, window, realClock, rand.Float64, warmPost)
+	log.Printf("Cache warm-up finished: %d posts over %s", len(ids), window)
+}
+
+// warmPosts calls warm for each id at its scheduled time within window
+func warmPosts(ids []int, window time.Duration, clock warmupClock, random func() float64, warm func(id int)) {
+	start := clock.now()
+	for i, delay := range warmupSchedule(len(ids), window, random) {
+		clock.sleep(start.Add(delay).Sub(clock.now()))
+		warm(ids[i])
+	}
+}
+
+// warmPost caches one post unless traffic already has. The post is read
+// again rather than taken from the warm-up query, which may be most of the
+// window old by now: a post updated or deleted since must not have its old
+// copy put back. loadPost also shares the read with any concurrent miss.
+func warmPost(id int) {
+	if _, _, found := cache.GetCachedPost(id); found {
+		return
+	}
+	if _, err := loadPost(context.Background(), id); err != nil && !errors.Is(err, errPostNotFound) {
+		log.Printf("Cache warm-up of post %d failed: %v", id, err)
+	}
+}
+
+// warmupSchedule gives each of n items a write time inside window: one evenly
+// sized slot per item, with a random offset inside the slot. Slots don't
+// overlap, so the offsets come out ascending and the caller can sleep between them.
+func warmupSchedule(n int, window time.Duration, random func() float64) []time.Duration {
+	if n == 0 {
+		return nil
+	}
+	slot := window / time.Duration(n)
+	delays := make([]time.Duration, n)
+	for i := range delays {
+		delays[i] = time.Duration(i)*slot + time.Duration(random()*float64(slot))
+	}
+	return delays
+}
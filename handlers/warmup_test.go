@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when the code under test sleeps
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) warmupClock() warmupClock {
+	return warmupClock{
+		now: func() time.Time { return c.t },
+		sleep: func(d time.Duration) {
+			if d > 0 {
+				c.t = c.t.Add(d)
+			}
+		},
+	}
+}
+
+func TestWarmPostsSpreadsAcrossWindow(t *testing.T) {
+	const (
+		n      = 100
+		window = 10 * time.Second
+	)
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	start := clock.t
+	ids := make([]int, n)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+
+	var at []time.Duration
+	var warmed []int
+	random := rand.New(rand.NewSource(1)).Float64
+	warmPosts(ids, window, clock.warmupClock(), random, func(id int) {
+		at = append(at, clock.t.Sub(start))
+		warmed = append(warmed, id)
+	})
+
+	if len(warmed) != n {
+		t.Fatalf("warmed %d posts, want %d", len(warmed), n)
+	}
+	for i, id := range warmed {
+		if id != ids[i] {
+			t.Fatalf("post %d warmed at position %d, want %d", id, i, ids[i])
+		}
+	}
+
+	// Every write falls inside the window, in order, and no second of the
+	// window sees more than its share: a spike would put them all at 0
+	perSecond := make(map[time.Duration]int)
+	for i, d := range at {
+		if d < 0 || d >= window {
+			t.Errorf("write %d at %s, outside the %s window", i, d, window)
+		}
+		if i > 0 && d < at[i-1] {
+			t.Errorf("write %d at %s, before write %d at %s", i, d, i-1, at[i-1])
+		}
+		perSecond[d.Truncate(time.Second)]++
+	}
+	share := n / int(window/time.Second)
+	for second, count := range perSecond {
+		if count > share+1 {
+			t.Errorf("%d writes in second %s, want at most %d", count, second, share+1)
+		}
+	}
+}
+
+func TestWarmupScheduleSlots(t *testing.T) {
+	const window = 4 * time.Second
+	// The largest offset the random source can give lands just inside each slot
+	delays := warmupSchedule(4, window, func() float64 { return 0.999 })
+	for i, d := range delays {
+		slotStart := time.Duration(i) * time.Second
+		if d < slotStart || d >= slotStart+time.Second {
+			t.Errorf("delay %d = %s, want within [%s, %s)", i, d, slotStart, slotStart+time.Second)
+		}
+	}
+	if got := warmupSchedule(0, window, rand.Float64); got != nil {
+		t.Errorf("schedule for no posts = %v, want nil", got)
+	}
+}
@@ -0,0 +1,82 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Component is a subsystem with a start/stop lifecycle. Either hook may be
+// nil, e.g. a connection opened before registration only needs Stop.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager starts components in registration order and stops them in reverse,
+// so anything registered later (the HTTP server, background workers) is shut
+// down before the connections it depends on
+type Manager struct {
+	mu         sync.Mutex
+	components []Component
+	started    []Component
+}
+
+func New() *Manager {
+	return &Manager{}
+}
+
+// Register adds a component. Register dependencies before their dependents.
+func (m *Manager) Register(c Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, c)
+}
+
+// Start runs every component's Start hook in order. If one fails, the ones
+// already started are stopped again before the error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	components := m.components
+	m.mu.Unlock()
+
+	for _, c := range components {
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				m.Stop(ctx)
+				return fmt.Errorf("starting %s: %w", c.Name, err)
+			}
+		}
+		m.mu.Lock()
+		m.started = append(m.started, c)
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop runs the Stop hooks of started components in reverse order. Every
+// component gets its turn even if an earlier one fails or ctx expires; the
+// errors are joined.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	started := m.started
+	m.started = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		c := started[i]
+		if c.Stop == nil {
+			continue
+		}
+		log.Printf("Stopping %s", c.Name)
+		if err := c.Stop(ctx); err != nil {
+			log.Printf("Error stopping %s: %v", c.Name, err)
+			errs = append(errs, fmt.Errorf("stopping %s: %w", c.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,94 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// recorder logs hook calls as "start name" and "stop name"
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) component(name string, startErr, stopErr error) Component {
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			r.calls = append(r.calls, "start "+name)
+			return startErr
+		},
+		Stop: func(context.Context) error {
+			r.calls = append(r.calls, "stop "+name)
+			return stopErr
+		},
+	}
+}
+
+func TestStopsInReverseOrder(t *testing.T) {
+	var rec recorder
+	m := New()
+	for _, name := range []string{"mongo", "redis", "workers", "http"} {
+		m.Register(rec.component(name, nil, nil))
+	}
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"start mongo", "start redis", "start workers", "start http",
+		"stop http", "stop workers", "stop redis", "stop mongo",
+	}
+	if !slices.Equal(rec.calls, want) {
+		t.Errorf("calls %v, want %v", rec.calls, want)
+	}
+
+	// A second Stop has nothing left to stop
+	rec.calls = nil
+	m.Stop(context.Background())
+	if len(rec.calls) != 0 {
+		t.Errorf("second Stop called %v", rec.calls)
+	}
+}
+
+func TestFailedStartStopsStartedComponents(t *testing.T) {
+	var rec recorder
+	boom := errors.New("boom")
+	m := New()
+	m.Register(rec.component("mongo", nil, nil))
+	m.Register(rec.component("redis", nil, nil))
+	m.Register(rec.component("http", boom, nil))
+	m.Register(rec.component("never", nil, nil))
+
+	if err := m.Start(context.Background()); !errors.Is(err, boom) {
+		t.Fatalf("Start error %v, want boom", err)
+	}
+	want := []string{"start mongo", "start redis", "start http", "stop redis", "stop mongo"}
+	if !slices.Equal(rec.calls, want) {
+		t.Errorf("calls %v, want %v", rec.calls, want)
+	}
+}
+
+func TestStopContinuesPastErrors(t *testing.T) {
+	var rec recorder
+	first, second := errors.New("first"), errors.New("second")
+	m := New()
+	m.Register(rec.component("mongo", nil, first))
+	m.Register(Component{Name: "conn"}) // no hooks
+	m.Register(rec.component("http", nil, second))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	err := m.Stop(context.Background())
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Errorf("Stop error %v, want both failures joined", err)
+	}
+	if want := []string{"start mongo", "start http", "stop http", "stop mongo"}; !slices.Equal(rec.calls, want) {
+		t.Errorf("calls %v, want %v", rec.calls, want)
+	}
+}
@@ -0,0 +1,78 @@
+package listener
+
+import (
+	"log"
+	"net"
+	"sync"
+)
+
+// LimitPerIP wraps l so each client IP holds at most max open connections.
+// Connections past the cap are closed as soon as they are accepted, which
+// guards against connection exhaustion regardless of request rate.
+func LimitPerIP(l net.Listener, max int) net.Listener {
+	return &perIPListener{Listener: l, max: max, open: make(map[string]int)}
+}
+
+type perIPListener struct {
+	net.Listener
+	max int
+
+	mu   sync.Mutex
+	open map[string]int
+}
+
+func (l *perIPListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		ip := remoteIP(conn)
+		if !l.acquire(ip) {
+			log.Printf("Rejecting connection from %s: over %d connections", ip, l.max)
+			conn.Close()
+			continue
+		}
+		return &trackedConn{Conn: conn, release: func() { l.release(ip) }}, nil
+	}
+}
+
+func (l *perIPListener) acquire(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.open[ip] >= l.max {
+		return false
+	}
+	l.open[ip]++
+	return true
+}
+
+func (l *perIPListener) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.open[ip]--; l.open[ip] <= 0 {
+		delete(l.open, ip)
+	}
+}
+
+func remoteIP(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+	return host
+}
+
+// trackedConn gives its slot back exactly once, however often it's closed
+type trackedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
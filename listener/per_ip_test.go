@@ -0,0 +1,98 @@
+package listener
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// closedByServer reports whether the server hung up on conn, as opposed to
+// keeping it open
+func closedByServer(t *testing.T, conn net.Conn) bool {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	_, err := conn.Read(make([]byte, 1))
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return false
+	}
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+		t.Logf("read: %v", err)
+	}
+	return true
+}
+
+func TestLimitPerIPCapsConnections(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := LimitPerIP(inner, 3)
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("tcp", inner.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	var clients []net.Conn
+	for i := 0; i < 5; i++ {
+		clients = append(clients, dial())
+	}
+	var held []net.Conn
+	for i := 0; i < 3; i++ {
+		select {
+		case conn := <-accepted:
+			held = append(held, conn)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("only %d connections accepted, want 3", i)
+		}
+	}
+
+	rejected := 0
+	for _, c := range clients {
+		if closedByServer(t, c) {
+			rejected++
+		}
+	}
+	if rejected != 2 {
+		t.Errorf("%d of 5 connections closed by the server, want 2", rejected)
+	}
+	select {
+	case <-accepted:
+		t.Error("a fourth connection was handed to the server")
+	default:
+	}
+
+	// Closing one frees its slot for the next connection
+	held[0].Close()
+	held[0].Close() // a second Close must not free a second slot
+	dial()
+	select {
+	case conn := <-accepted:
+		defer conn.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("slot not released after a connection closed")
+	}
+	late := dial()
+	if !closedByServer(t, late) {
+		t.Error("double Close freed an extra slot")
+	}
+}
@@ -2,61 +2,195 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"go-server/cache"
 	"go-server/db"
 	"go-server/handlers"
+	"go-server/lifecycle"
+	"go-server/listener"
+	"go-server/metrics"
+	"go-server/middleware"
+	"go-server/models"
+	"go-server/utils"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"sync"
+	"syscall"
+	"time"
 
-	"github.com/go-redis/redis"
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // define c Post class with ID, Body attributes
 
 var (
-	nextID      = 1        // variable helps us to make unique post ids when making new post
-	postsMu     sync.Mutex // mutex to lock programwhen changing to the posts map (concurrent request causes race condition --> access the same resources at the same time)
-	ctx         = context.Background()
-	client      *mongo.Client
-	redisClient *redis.Client
+	postsMu sync.Mutex // mutex to lock programwhen changing to the posts map (concurrent request causes race condition --> access the same resources at the same time)
+	ctx     = context.Background()
 )
 
-func initNextID() {
-	// Ensure MongoDB client and collection are initialized
-	if db.PostCol == nil {
-		log.Fatal("MongoDB collection is nil. Cannot initialize nextID.")
+const (
+	// defaultShutdownTimeout bounds how long components get to stop on exit
+	defaultShutdownTimeout = 15 * time.Second
+	// dependencyRetryInterval spaces MongoDB connection attempts while
+	// WAIT_FOR_DEPENDENCIES holds the server back
+	dependencyRetryInterval = 2 * time.Second
+)
+
+// strictStartup reports whether a missing dependency should stop the server.
+// Defaults to true; set STRICT_STARTUP=false to boot degraded.
+func strictStartup() bool {
+	strict, err := strconv.ParseBool(os.Getenv("STRICT_STARTUP"))
+	if err != nil {
+		return true
+	}
+	return strict
+}
+
+// shutdownTimeout reads SHUTDOWN_TIMEOUT, how long in-flight requests get to
+// finish and connections get to close before the process exits anyway
+func shutdownTimeout() time.Duration {
+	raw := os.Getenv("SHUTDOWN_TIMEOUT")
+	if raw == "" {
+		return defaultShutdownTimeout
 	}
+	timeout, err := time.ParseDuration(raw)
+	if err != nil || timeout <= 0 {
+		log.Fatalf("Invalid SHUTDOWN_TIMEOUT %q: must be a positive duration", raw)
+	}
+	return timeout
+}
 
-	var result struct {
-		MaxID int `bson:"maxID"`
+// httpIdleTimeout reads HTTP_IDLE_TIMEOUT, how long a keep-alive connection
+// may sit idle between requests before the server closes it. Unset means no
+// limit.
+func httpIdleTimeout() time.Duration {
+	raw := os.Getenv("HTTP_IDLE_TIMEOUT")
+	if raw == "" {
+		return 0
 	}
-	// MongoDB aggregation pipeline to get the max ID
-	pipeline := mongo.Pipeline{
-		{{"$sort", bson.D{{"id", -1}}}},
-		{{"$limit", 1}},
-		{{"$project", bson.D{{"maxID", "$id"}}}},
+	timeout, err := time.ParseDuration(raw)
+	if err != nil || timeout <= 0 {
+		log.Fatalf("Invalid HTTP_IDLE_TIMEOUT %q: must be a positive duration", raw)
 	}
+	return timeout
+}
 
-	cursor, err := db.PostCol.Aggregate(context.Background(), pipeline)
+// disableKeepAlivesOnDrain reads DISABLE_KEEPALIVES. Defaults to true: once
+// shutdown starts, keep-alives are switched off so no connection is reused
+// while the server drains.
+func disableKeepAlivesOnDrain() bool {
+	disable, err := strconv.ParseBool(os.Getenv("DISABLE_KEEPALIVES"))
 	if err != nil {
-		log.Printf("Failed to aggregate max ID: %v", err)
-		return
+		return true
+	}
+	return disable
+}
+
+// stopServer drains srv, first switching keep-alives off when drainKeepAlives
+// is set
+func stopServer(srv *http.Server, drainKeepAlives bool) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if drainKeepAlives {
+			// Idle connections close now and in-flight responses carry
+			// Connection: close, so clients reconnect elsewhere
+			srv.SetKeepAlivesEnabled(false)
+		}
+		return srv.Shutdown(ctx)
 	}
-	defer cursor.Close(context.Background())
+}
+
+// maxConnPerIP reads MAX_CONN_PER_IP, the most concurrent connections one
+// client IP may hold. Unset or 0 means no limit.
+func maxConnPerIP() int {
+	raw := os.Getenv("MAX_CONN_PER_IP")
+	if raw == "" {
+		return 0
+	}
+	max, err := strconv.Atoi(raw)
+	if err != nil || max < 0 {
+		log.Fatalf("Invalid MAX_CONN_PER_IP %q: must be a non-negative number", raw)
+	}
+	return max
+}
+
+func logReadinessSummary(mongoErr, redisErr error) {
+	status := func(err error) string {
+		if err != nil {
+			return fmt.Sprintf("unavailable (%v)", err)
+		}
+		return "ok"
+	}
+	log.Printf("Startup readiness: mongo=%s redis=%s", status(mongoErr), status(redisErr))
+}
+
+// waitForDependencies reads WAIT_FOR_DEPENDENCIES. When true the server starts
+// listening straight away but answers 503 (except /healthz) until MongoDB is
+// connected, retrying the connection instead of giving up.
+func waitForDependencies() bool {
+	wait, _ := strconv.ParseBool(os.Getenv("WAIT_FOR_DEPENDENCIES"))
+	return wait
+}
 
-	if cursor.Next(context.Background()) {
-		if err := cursor.Decode(&result); err == nil {
-			nextID = result.MaxID + 1
-			log.Printf("Next ID set to: %d", nextID)
+// connectDependencies connects to MongoDB and Redis, then seeds and warms
+// what depends on them. With wait set, MongoDB is retried until it answers
+// and STRICT_STARTUP doesn't apply.
+func connectDependencies(wait bool) {
+	// Try every dependency before deciding whether to stop, so one outage
+	// doesn't hide the state of the others
+	mongoErr := db.InitMongoDB()
+	redisErr := cache.InitRedis()
+	for wait && mongoErr != nil {
+		log.Printf("MongoDB not ready, retrying in %s: %v", dependencyRetryInterval, mongoErr)
+		time.Sleep(dependencyRetryInterval)
+		mongoErr = db.InitMongoDB()
+	}
+	logReadinessSummary(mongoErr, redisErr)
+	if mongoErr != nil {
+		if strictStartup() {
+			log.Fatalf("MongoDB unavailable: %v", mongoErr)
 		}
+		log.Println("STRICT_STARTUP=false, starting in degraded mode without MongoDB")
+	}
+	initNextID()
+	handlers.StartCacheWarmup()
+}
+
+// logStartupSummary writes the effective configuration as one JSON log line,
+// with secrets redacted, once the server is listening and connected
+func logStartupSummary(addr string) {
+	summary, err := json.Marshal(struct {
+		Addr string `json:"addr"`
+		handlers.RuntimeConfig
+	}{addr, handlers.CurrentConfig()})
+	if err != nil {
+		log.Printf("Error encoding startup summary: %v", err)
+		return
+	}
+	log.Printf("Startup summary: %s", summary)
+}
+
+func initNextID() {
+	// Nothing to seed from while running without MongoDB
+	if db.PostCol == nil {
+		log.Println("MongoDB collection is nil, skipping post id counter initialization")
+		return
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	maxID, err := db.SeedPostIDs(ctx)
+	if err != nil {
+		log.Printf("Failed to seed post id counter: %v", err)
+		return
+	}
+	log.Printf("Post id counter is at least %d", maxID)
 }
 
 // Implementing server
@@ -67,10 +201,53 @@ func main() {
 			log.Println("No .env file found, continuing...")
 		}
 	}
-	db.InitMongoDB()
-	fmt.Println("MongoDB Collection initialized:", db.PostCol)
-	cache.InitRedis()
-	initNextID()
+	cache.SubscribeToPostEvents()
+	cache.InitRouteCaching()
+	cache.InitWritePolicy()
+	metrics.RegisterCacheState(cache.Enabled)
+	cache.InitCacheTTL()
+	cache.InitTTLJitter()
+	cache.InitMaxValueSize()
+	cache.InitCompression()
+	db.InitReadRetries()
+	db.InitListLimits()
+	utils.InitPageLimits()
+	utils.InitDuplicateParams()
+	models.InitNormalization()
+	models.InitBodyPolicy()
+	middleware.InitGzip()
+	middleware.InitRequestDecompression()
+	middleware.InitLogging()
+	handlers.InitCursorLimit()
+	handlers.InitHealthChecks()
+	handlers.InitContentPolicy()
+	handlers.InitReadMode()
+	handlers.InitUpdateLimits()
+	handlers.InitEmptyUpdatePolicy()
+	handlers.InitStaleFallback()
+	handlers.InitDeletePolicy()
+	handlers.InitRootMode()
+	handlers.InitNegotiation()
+	handlers.InitDistinctLimit()
+	handlers.InitCacheWarmup()
+	watchReload()
+	// Checked before connecting so a CORS misconfiguration fails fast
+	corsOpts := corsOptions()
+
+	var gate middleware.StartupGate
+	connected := make(chan struct{})
+	if waitForDependencies() {
+		log.Println("WAIT_FOR_DEPENDENCIES=true, answering 503 until MongoDB is connected")
+		go func() {
+			connectDependencies(true)
+			gate.Open()
+			close(connected)
+		}()
+	} else {
+		connectDependencies(false)
+		gate.Open()
+		close(connected)
+	}
 
 	// Create a new mux router
 	mux := http.NewServeMux()
@@ -78,54 +255,109 @@ func main() {
 	// setup handlers for the /posts and /posts routes
 	mux.HandleFunc("/posts", handlers.PostsHandler)
 	mux.HandleFunc("/posts/", handlers.PostHandler)
+	mux.HandleFunc("/posts/validate", handlers.ValidatePostHandler)
+	mux.HandleFunc("/posts/changes", handlers.PostChangesHandler)
+	mux.HandleFunc("/posts/findOrCreate", handlers.FindOrCreatePostHandler)
+	mux.HandleFunc("/posts/feed.xml", handlers.PostsFeedHandler)
+	mux.HandleFunc("/posts/batch", handlers.BatchGetPostsHandler)
+	mux.HandleFunc("/posts/search", handlers.SearchPostsHandler)
+	mux.HandleFunc("/posts/extremes", handlers.PostExtremesHandler)
+	mux.HandleFunc("/posts/distinct", handlers.DistinctValuesHandler)
+	mux.HandleFunc("/posts/tail", handlers.PostsTailHandler)
+	mux.HandleFunc("/posts/recent", handlers.RecentPostsHandler)
+	mux.HandleFunc("/posts/count/history", handlers.PostCountHistoryHandler)
+	mux.HandleFunc("/posts/export.zip", handlers.ExportZipHandler)
+	mux.HandleFunc("/metrics", metrics.Handler)
+	mux.HandleFunc("/healthz", handlers.HealthzHandler)
+	mux.HandleFunc("/readyz", handlers.ReadyzHandler)
+	mux.HandleFunc("/", handlers.RootHandler([]string{
+		"/posts", "/posts/{id}", "/posts/validate", "/posts/changes", "/posts/findOrCreate",
+		"/posts/feed.xml", "/posts/batch", "/posts/search", "/posts/extremes", "/posts/distinct",
+		"/posts/tail", "/posts/recent", "/posts/count/history",
+		"/posts/export.zip",
+		"/metrics", "/healthz", "/readyz",
+	}))
+	mux.HandleFunc("/admin/config", middleware.RequireAdmin(handlers.AdminConfigHandler))
+	mux.HandleFunc("/admin/reindex", middleware.RequireAdmin(handlers.AdminReindexHandler))
+	mux.HandleFunc("/admin/cache/", middleware.RequireAdmin(handlers.AdminCacheEntryHandler))
+	mux.HandleFunc("/admin/cache/prime/", middleware.RequireAdmin(handlers.AdminCachePrimeHandler))
+	mux.HandleFunc("/admin/cache/purge", middleware.RequireAdmin(handlers.AdminCachePurgeHandler))
+	mux.HandleFunc("/admin/cache/verify", middleware.RequireAdmin(handlers.AdminCacheVerifyHandler))
+	mux.HandleFunc("/admin/posts/", middleware.RequireAdmin(handlers.AdminPostDiffHandler))
+	mux.HandleFunc("/admin/db/stats", middleware.RequireAdmin(handlers.AdminDBStatsHandler))
+	mux.HandleFunc("/admin/counters/", middleware.RequireAdmin(handlers.AdminCounterNextHandler))
 
 	// Configure CORS
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:3001"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
-		AllowCredentials: true,
-	})
+	c := cors.New(corsOpts)
 
-	// Wrap the mux with CORS middleware
-	handler := c.Handler(mux)
+	// Wrap the mux with hop-by-hop header handling, logging, the startup gate,
+	// CORS, response compression, request decompression, duplicate query
+	// parameter and DB op counting middleware
+	handler := middleware.HopByHop(middleware.Logging(gate.Handler(c.Handler(middleware.Gzip(middleware.Gunzip(middleware.DuplicateParams(middleware.DBOps(middleware.CacheTrace(mux)))))))))
 
-	// Graceful shutdown handling
-	ctx, cancel := context.WithCancel(context.Background())
-	fmt.Println(ctx)
-	defer cancel()
+	srv := &http.Server{Handler: handler, IdleTimeout: httpIdleTimeout()}
+	srv.RegisterOnShutdown(handlers.CloseTailStreams)
+	drainKeepAlives := disableKeepAlivesOnDrain()
+
+	// Components stop in reverse registration order: register dependencies
+	// first, so the HTTP server drains before the connections it uses close
+	components := lifecycle.New()
+	components.Register(lifecycle.Component{Name: "mongo", Stop: db.Close})
+	components.Register(lifecycle.Component{Name: "redis", Stop: cache.Close})
+	components.Register(lifecycle.Component{
+		Name:  "count-snapshots",
+		Start: handlers.StartCountSnapshots,
+		Stop:  handlers.StopCountSnapshots,
+	})
+	components.Register(lifecycle.Component{Name: "http", Stop: stopServer(srv, drainKeepAlives)})
+	if err := components.Start(context.Background()); err != nil {
+		log.Fatalf("Startup failed: %v", err)
+	}
 
+	// Graceful shutdown handling: on SIGINT/SIGTERM the HTTP server drains
+	// in-flight requests, then MongoDB and Redis are disconnected
+	timeout := shutdownTimeout()
+	stopped := make(chan error, 1)
 	go func() {
 		c := make(chan os.Signal, 1)
-		// signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 		<-c
 		log.Println("Shutting down...")
-		cancel()
-
-		// Close MongoDB connection
-		if err := client.Disconnect(context.Background()); err != nil {
-			log.Printf("MongoDB disconnect error: %v", err)
-		}
-
-		// Close Redis connection
-		if redisClient != nil {
-			if err := redisClient.Close(); err != nil {
-				log.Printf("Redis close error: %v", err)
-			}
-		}
+		// A second signal skips the drain
+		signal.Stop(c)
 
-		os.Exit(0)
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), timeout)
+		defer stopCancel()
+		stopped <- components.Stop(stopCtx)
 	}()
 
 	fmt.Println("Server is running at http://localhost:8080")
 	/*
 		log: recording program events, including errors
 		log.Fatal(): logs a message and then calls os.Exit(1), terminating the program
-		http.ListenAndServe: starts an HTTP server, port 8080
+		net.Listen + http.Serve: starts an HTTP server, port 8080
 		nil: use default HTTP handler
 
 		==> start an HTTP server
 
 	*/
-	log.Fatal(http.ListenAndServe(":8080", handler))
+	ln, err := net.Listen("tcp", ":8080")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if max := maxConnPerIP(); max > 0 {
+		ln = listener.LimitPerIP(ln, max)
+	}
+	go func() {
+		<-connected
+		logStartupSummary(ln.Addr().String())
+	}()
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	// Serve returns as soon as draining starts; wait for the rest of shutdown
+	if err := <-stopped; err != nil {
+		log.Fatalf("Shutdown error: %v", err)
+	}
+	log.Println("Server stopped cleanly")
 }
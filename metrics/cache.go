@@ -0,0 +1,36 @@
+package metrics
+
+// Endpoint labels for cache metrics
+const (
+	EndpointPost = "post"
+	EndpointList = "list"
+)
+
+// CacheLookups counts cache hits and misses per endpoint, so operators can
+// see which access pattern benefits from caching
+var CacheLookups = NewCounterVec(
+	"cache_lookups_total",
+	"Cache lookups partitioned by endpoint and result (hit or miss).",
+	"endpoint", "result",
+)
+
+// CacheHit records a cache hit for endpoint
+func CacheHit(endpoint string) {
+	CacheLookups.Inc(endpoint, "hit")
+}
+
+// CacheMiss records a cache miss for endpoint
+func CacheMiss(endpoint string) {
+	CacheLookups.Inc(endpoint, "miss")
+}
+
+// RegisterCacheState exposes cache_disabled, 1 while the server runs without
+// a cache, so degraded mode shows up on dashboards
+func RegisterCacheState(enabled func() bool) {
+	NewGaugeFunc("cache_disabled", "Whether the server is running without a cache (1) or not (0).", func() float64 {
+		if enabled() {
+			return 0
+		}
+		return 1
+	})
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CounterVec is a family of counters sharing a name and label names, written
+// out in the Prometheus text exposition format
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// collector is anything that can write itself out for /metrics
+type collector interface {
+	write(sb *strings.Builder)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []collector
+)
+
+func register(c collector) {
+	registryMu.Lock()
+	registry = append(registry, c)
+	registryMu.Unlock()
+}
+
+// NewCounterVec creates a counter family and registers it for /metrics
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]float64),
+	}
+
+	register(c)
+	return c
+}
+
+// Inc adds one to the counter identified by labelValues, given in the same
+// order as the label names
+func (c *CounterVec) Inc(labelValues ...string) {
+	key := c.labelKey(labelValues)
+	c.mu.Lock()
+	c.values[key]++
+	c.mu.Unlock()
+}
+
+// Value returns the current count for labelValues
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	key := c.labelKey(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+func (c *CounterVec) labelKey(labelValues []string) string {
+	pairs := make([]string, len(c.labelNames))
+	for i, name := range c.labelNames {
+		value := ""
+		if i < len(labelValues) {
+			value = labelValues[i]
+		}
+		pairs[i] = fmt.Sprintf("%s=%q", name, value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (c *CounterVec) write(sb *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(sb, "# HELP %s %s\n", c.name, c.help)
+	fmt.Fprintf(sb, "# TYPE %s counter\n", c.name)
+
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(sb, "%s{%s} %g\n", c.name, key, c.values[key])
+	}
+}
+
+// GaugeFunc is a gauge whose value is read at scrape time, for state owned
+// elsewhere such as whether a dependency is connected
+type GaugeFunc struct {
+	name  string
+	help  string
+	value func() float64
+}
+
+// NewGaugeFunc creates a gauge backed by value and registers it for /metrics
+func NewGaugeFunc(name, help string, value func() float64) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, value: value}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(sb *strings.Builder) {
+	fmt.Fprintf(sb, "# HELP %s %s\n", g.name, g.help)
+	fmt.Fprintf(sb, "# TYPE %s gauge\n", g.name)
+	fmt.Fprintf(sb, "%s %g\n", g.name, g.value())
+}
+
+// Handler serves every registered metric for Prometheus to scrape
+func Handler(w http.ResponseWriter, r *http.Request) {
+	var sb strings.Builder
+
+	registryMu.Lock()
+	for _, c := range registry {
+		c.write(&sb)
+	}
+	registryMu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(sb.String()))
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounterVecLabelsAreIndependent(t *testing.T) {
+	c := NewCounterVec("test_lookups_total", "Test lookups.", "endpoint", "result")
+	c.Inc("post", "hit")
+	c.Inc("post", "hit")
+	c.Inc("list", "miss")
+
+	for _, tt := range []struct {
+		labels []string
+		want   float64
+	}{
+		{[]string{"post", "hit"}, 2},
+		{[]string{"post", "miss"}, 0},
+		{[]string{"list", "hit"}, 0},
+		{[]string{"list", "miss"}, 1},
+	} {
+		if got := c.Value(tt.labels...); got != tt.want {
+			t.Errorf("%v = %g, want %g", tt.labels, got, tt.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE test_lookups_total counter",
+		`test_lookups_total{endpoint="post",result="hit"} 2`,
+		`test_lookups_total{endpoint="list",result="miss"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("/metrics is missing %q:\n%s", line, body)
+		}
+	}
+}
+
+func TestGaugeFuncReadsAtScrapeTime(t *testing.T) {
+	value := 0.0
+	NewGaugeFunc("test_gauge", "Test gauge.", func() float64 { return value })
+	value = 1
+
+	rec := httptest.NewRecorder()
+	Handler(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "test_gauge 1\n") {
+		t.Errorf("gauge not read at scrape time:\n%s", rec.Body)
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"go-server/utils"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// RequireAdmin only lets requests through when they present the ADMIN_TOKEN,
+// either as a bearer token or in the X-Admin-Token header. Admin routes are
+// disabled entirely when no token is configured.
+func RequireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv("ADMIN_TOKEN")
+		if token == "" {
+			utils.Error(w, "Admin endpoints are disabled", http.StatusForbidden)
+			return
+		}
+
+		if !hasAdminToken(r, token) {
+			utils.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// hasAdminToken reports whether r presents token, either as a bearer token or
+// in the X-Admin-Token header
+func hasAdminToken(r *http.Request, token string) bool {
+	supplied := r.Header.Get("X-Admin-Token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		supplied = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(supplied), []byte(token)) == 1
+}
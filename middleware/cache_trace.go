@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"go-server/cache"
+	"net/http"
+)
+
+// CacheTrace reports the cache decisions behind a response in an
+// X-Cache-Trace header, e.g. "redis=miss, singleflight=shared, db=hit". It is
+// gated like X-DB-Ops and left off responses that never touched the cache.
+func CacheTrace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !exposeDebugHeaders(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		r = r.WithContext(cache.WithTrace(r.Context()))
+		next.ServeHTTP(&debugHeaderWriter{ResponseWriter: w, set: func(h http.Header) {
+			if trace := cache.TraceString(r.Context()); trace != "" {
+				h.Set("X-Cache-Trace", trace)
+			}
+		}}, r)
+	})
+}
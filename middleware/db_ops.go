@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"go-server/db"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// DBOps reports how many MongoDB commands a request issued in an X-DB-Ops
+// header, to make N+1 query patterns visible. Outside production every
+// response carries it; in production only requests with the ADMIN_TOKEN do.
+func DBOps(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !exposeDebugHeaders(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		r = r.WithContext(db.WithOpCounter(r.Context()))
+		next.ServeHTTP(&debugHeaderWriter{ResponseWriter: w, set: func(h http.Header) {
+			h.Set("X-DB-Ops", strconv.FormatInt(db.OpCount(r.Context()), 10))
+		}}, r)
+	})
+}
+
+// exposeDebugHeaders decides whether a request gets diagnostic headers:
+// always outside production, and only with the ADMIN_TOKEN inside it
+func exposeDebugHeaders(r *http.Request) bool {
+	if os.Getenv("ENV") != "production" {
+		return true
+	}
+	token := os.Getenv("ADMIN_TOKEN")
+	return token != "" && hasAdminToken(r, token)
+}
+
+// debugHeaderWriter calls set just before the headers go out, by which point
+// the handler has done the work the header describes
+type debugHeaderWriter struct {
+	http.ResponseWriter
+	set         func(http.Header)
+	wroteHeader bool
+}
+
+func (d *debugHeaderWriter) WriteHeader(statusCode int) {
+	if !d.wroteHeader {
+		d.wroteHeader = true
+		d.set(d.Header())
+	}
+	d.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (d *debugHeaderWriter) Write(b []byte) (int, error) {
+	if !d.wroteHeader {
+		d.WriteHeader(http.StatusOK)
+	}
+	return d.ResponseWriter.Write(b)
+}
+
+func (d *debugHeaderWriter) Flush() {
+	if f, ok := d.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
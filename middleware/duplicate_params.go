@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"go-server/utils"
+	"net/http"
+)
+
+// DuplicateParams rejects requests that repeat a query parameter when
+// DUPLICATE_PARAMS=reject; under the other policies it does nothing and
+// utils.QueryParam picks the value
+func DuplicateParams(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := utils.CheckDuplicateParams(r); err != nil {
+			utils.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
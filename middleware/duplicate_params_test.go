@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"go-server/utils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestDuplicateParamsReject(t *testing.T) {
+	t.Setenv("DUPLICATE_PARAMS", utils.DuplicateParamsReject)
+	utils.InitDuplicateParams()
+	t.Cleanup(func() {
+		os.Setenv("DUPLICATE_PARAMS", utils.DuplicateParamsFirst)
+		utils.InitDuplicateParams()
+	})
+
+	reached := false
+	h := DuplicateParams(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { reached = true }))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts?limit=5&limit=10", nil))
+	if rec.Code != http.StatusBadRequest || reached {
+		t.Errorf("repeated limit: status %d, handler reached %v; want 400 and not reached", rec.Code, reached)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/posts?limit=5&offset=10", nil))
+	if rec.Code != http.StatusOK || !reached {
+		t.Errorf("distinct params: status %d, handler reached %v", rec.Code, reached)
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"go-server/utils"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Without a cap a few KB of gzip can expand to gigabytes
+const defaultMaxDecompressedBytes = 10 << 20
+
+var maxDecompressedBytes int64 = defaultMaxDecompressedBytes
+
+// InitRequestDecompression reads MAX_DECOMPRESSED_BYTES, the most a gzipped
+// request body may expand to
+func InitRequestDecompression() {
+	raw := os.Getenv("MAX_DECOMPRESSED_BYTES")
+	if raw == "" {
+		return
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n <= 0 {
+		log.Fatalf("Invalid MAX_DECOMPRESSED_BYTES %q: must be a positive number of bytes", raw)
+	}
+	maxDecompressedBytes = n
+}
+
+// Gunzip transparently decompresses request bodies sent with
+// Content-Encoding: gzip. A malformed stream is a 400; a body that expands
+// past MAX_DECOMPRESSED_BYTES fails to read with *http.MaxBytesError, which
+// handlers report as 413.
+func Gunzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := strings.TrimSpace(r.Header.Get("Content-Encoding"))
+		if encoding == "" || strings.EqualFold(encoding, "identity") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !strings.EqualFold(encoding, "gzip") {
+			utils.Error(w, "Unsupported Content-Encoding "+strconv.Quote(encoding), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			utils.Error(w, "Malformed gzip request body", http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+
+		r.Body = http.MaxBytesReader(w, gz, maxDecompressedBytes)
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+		r.ContentLength = -1
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// importHandler stands in for a bulk import: it reads the whole body and
+// answers 413 when the read hits the limit, like the real write handlers
+func importHandler(got *[]byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		*got = body
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func serveGunzip(h http.Handler, encoding string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/posts/import", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	rec := httptest.NewRecorder()
+	Gunzip(h).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGunzipDecompressesImport(t *testing.T) {
+	posts := make([]map[string]string, 500)
+	for i := range posts {
+		posts[i] = map[string]string{"body": "imported post"}
+	}
+	plain, _ := json.Marshal(posts)
+
+	var got []byte
+	rec := serveGunzip(importHandler(&got), "gzip", gzipped(t, plain))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("handler read %d bytes, want the %d decompressed bytes", len(got), len(plain))
+	}
+
+	// Plain bodies pass through untouched
+	got = nil
+	if rec := serveGunzip(importHandler(&got), "", plain); rec.Code != http.StatusOK || !bytes.Equal(got, plain) {
+		t.Errorf("plain body: status %d, %d bytes", rec.Code, len(got))
+	}
+}
+
+func TestGunzipRejectsZipBomb(t *testing.T) {
+	defer func(n int64) { maxDecompressedBytes = n }(maxDecompressedBytes)
+	maxDecompressedBytes = 1 << 20
+
+	// 64MB of zeros compresses to under 200KB
+	bomb := gzipped(t, make([]byte, 64<<20))
+	if len(bomb) > 200<<10 {
+		t.Fatalf("bomb is %d bytes compressed", len(bomb))
+	}
+	var got []byte
+	if rec := serveGunzip(importHandler(&got), "gzip", bomb); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status %d, want 413", rec.Code)
+	}
+}
+
+func TestGunzipRejectsBadEncodings(t *testing.T) {
+	var got []byte
+	if rec := serveGunzip(importHandler(&got), "gzip", []byte("not gzip at all")); rec.Code != http.StatusBadRequest {
+		t.Errorf("malformed gzip: status %d, want 400", rec.Code)
+	}
+	if rec := serveGunzip(importHandler(&got), "br", []byte("{}")); rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("unsupported encoding: status %d, want 415", rec.Code)
+	}
+	if got != nil {
+		t.Errorf("handler ran for a rejected body")
+	}
+}
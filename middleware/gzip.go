@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const defaultGzipLevel = 6
+
+var gzipLevel = defaultGzipLevel
+
+// InitGzip reads GZIP_LEVEL and stops the server on an out-of-range value
+func InitGzip() {
+	level, err := getGzipLevel()
+	if err != nil {
+		log.Fatalf("Invalid GZIP_LEVEL: %v", err)
+	}
+	gzipLevel = level
+}
+
+// GzipLevel returns the compression level in effect
+func GzipLevel() int {
+	return gzipLevel
+}
+
+func getGzipLevel() (int, error) {
+	raw := os.Getenv("GZIP_LEVEL")
+	if raw == "" {
+		return defaultGzipLevel, nil
+	}
+
+	level, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", raw)
+	}
+	if level < gzip.BestSpeed || level > gzip.BestCompression {
+		return 0, fmt.Errorf("%d is outside the range %d-%d", level, gzip.BestSpeed, gzip.BestCompression)
+	}
+	return level, nil
+}
+
+// precompressedTypes gain nothing from gzip; image/svg+xml is text and is
+// not listed
+var precompressedTypes = []string{
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/zstd",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"video/",
+	"audio/",
+}
+
+// gzipResponseWriter holds back the header until the first non-empty Write,
+// when the status and content type are known, and only then decides whether
+// to compress
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	head    bool
+	status  int
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (g *gzipResponseWriter) WriteHeader(statusCode int) {
+	if g.decided || g.status != 0 {
+		return
+	}
+	// 1xx responses go out as they come; the final header follows
+	if statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols {
+		g.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	g.status = statusCode
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified || g.head {
+		// No body will follow, so there's nothing to wait for
+		g.decide(nil, false)
+	}
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !g.decided {
+		if len(b) == 0 {
+			return 0, nil
+		}
+		g.decide(b, true)
+	}
+	if g.gz != nil {
+		return g.gz.Write(b)
+	}
+	return g.ResponseWriter.Write(b)
+}
+
+// decide sends the header, compressing when a body follows and is worth it.
+// first is the start of the body, used to sniff a missing Content-Type the
+// way net/http would.
+func (g *gzipResponseWriter) decide(first []byte, body bool) {
+	g.decided = true
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	h := g.Header()
+	if first != nil && h.Get("Content-Type") == "" {
+		// net/http would otherwise sniff the compressed bytes
+		h.Set("Content-Type", http.DetectContentType(first))
+	}
+	if body && g.compressible() {
+		gz, err := gzip.NewWriterLevel(g.ResponseWriter, gzipLevel)
+		if err != nil {
+			log.Printf("Error creating gzip writer: %v", err)
+		} else {
+			g.gz = gz
+			h.Set("Content-Encoding", "gzip")
+			// the compressed length differs from whatever the handler computed
+			h.Del("Content-Length")
+		}
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+}
+
+func (g *gzipResponseWriter) compressible() bool {
+	if g.head || g.status == http.StatusNoContent || g.status == http.StatusNotModified {
+		return false
+	}
+	h := g.Header()
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := strings.ToLower(h.Get("Content-Type"))
+	for _, t := range precompressedTypes {
+		if strings.HasPrefix(contentType, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// close finishes the response: it flushes the gzip stream, or sends a header
+// the handler set without writing a body
+func (g *gzipResponseWriter) close() {
+	if g.gz != nil {
+		g.gz.Close()
+		return
+	}
+	if !g.decided && g.status != 0 {
+		g.decide(nil, false)
+	}
+}
+
+// Flush pushes out whatever has been compressed so far, so streaming
+// responses aren't held back by the gzip buffer
+func (g *gzipResponseWriter) Flush() {
+	if !g.decided {
+		// A streaming response flushing before its first write
+		g.decide(nil, true)
+	}
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Gzip compresses response bodies for clients that advertise gzip support,
+// leaving alone bodiless and already compressed responses
+func Gzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, head: r.Method == http.MethodHead}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
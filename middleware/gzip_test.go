@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveGzip(t *testing.T, method string, h http.HandlerFunc) *http.Response {
+	t.Helper()
+	srv := httptest.NewServer(Gzip(h))
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequest(method, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Set by hand so the transport doesn't decompress behind our back
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestGzipCompressesBody(t *testing.T) {
+	body := strings.Repeat(`{"id":1,"body":"hello"}`, 50)
+	resp := serveGzip(t, http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "9999")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, body)
+	})
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status %d, want 404", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("Content-Type = %q, want the uncompressed body sniffed", got)
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != body {
+		t.Errorf("body = %q, want %q", got, body)
+	}
+}
+
+func TestGzipSkipsResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		handler http.HandlerFunc
+	}{
+		{"no content", http.MethodDelete, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}},
+		{"not modified", http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `"1"`)
+			w.WriteHeader(http.StatusNotModified)
+		}},
+		{"head", http.MethodHead, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Length", "42")
+		}},
+		{"empty body", http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write(nil)
+		}},
+		{"zip", http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/zip")
+			io.WriteString(w, "PK\x03\x04 pretend archive")
+		}},
+		{"already encoded", http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			io.WriteString(w, "pretend brotli")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serveGzip(t, tt.method, tt.handler)
+			if got := resp.Header.Get("Content-Encoding"); got == "gzip" {
+				t.Error("response was gzipped")
+			}
+			if tt.name == "head" && resp.Header.Get("Content-Length") != "42" {
+				t.Errorf("HEAD lost its Content-Length: %q", resp.Header.Get("Content-Length"))
+			}
+		})
+	}
+}
+
+func TestGzipHeaderWithoutBody(t *testing.T) {
+	resp := serveGzip(t, http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/posts/7")
+		w.WriteHeader(http.StatusCreated)
+	})
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") != "/posts/7" {
+		t.Errorf("got %d with Location %q, want 201 with /posts/7", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if got := resp.Header.Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q on an empty body", got)
+	}
+}
+
+func TestGzipLevelTradesSizeForSpeed(t *testing.T) {
+	// Varied enough that the levels make different choices
+	var b strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&b, `{"id":%d,"body":"post number %d","tags":["t%d"]},`, i, i*7919%1000, i%13)
+	}
+	body := b.String()
+
+	sizes := map[int]int{}
+	for _, level := range []int{gzip.BestSpeed, gzip.BestCompression} {
+		gzipLevel = level
+		resp := serveGzip(t, http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, body)
+		})
+		compressed, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes[level] = len(compressed)
+	}
+	gzipLevel = defaultGzipLevel
+
+	if sizes[gzip.BestSpeed] <= sizes[gzip.BestCompression] {
+		t.Errorf("level 1 gave %d bytes, level 9 %d; want level 1 larger", sizes[gzip.BestSpeed], sizes[gzip.BestCompression])
+	}
+}
+
+func TestGetGzipLevel(t *testing.T) {
+	for raw, want := range map[string]int{"": defaultGzipLevel, "1": 1, "9": 9} {
+		t.Setenv("GZIP_LEVEL", raw)
+		if level, err := getGzipLevel(); err != nil || level != want {
+			t.Errorf("GZIP_LEVEL=%q: got %d, %v; want %d", raw, level, err, want)
+		}
+	}
+	for _, raw := range []string{"0", "10", "-1", "fast"} {
+		t.Setenv("GZIP_LEVEL", raw)
+		if _, err := getGzipLevel(); err == nil {
+			t.Errorf("GZIP_LEVEL=%q accepted", raw)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// hopByHopHeaders only concern a single connection (RFC 9110 §7.6.1) and
+// must not be forwarded by us or echoed back to the client
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// HopByHop hides hop-by-hop headers from handlers and strips them from
+// responses. It belongs at the front of the chain. Ambiguous framing needs no
+// check here: net/http rejects conflicting Content-Lengths and drops
+// Content-Length from chunked requests before any handler runs.
+func HopByHop(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		removeHopByHop(r.Header)
+		next.ServeHTTP(&hopByHopWriter{ResponseWriter: w}, r)
+	})
+}
+
+// removeHopByHop deletes the standard hop-by-hop headers plus any the
+// Connection header names
+func removeHopByHop(h http.Header) {
+	for _, value := range h.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = textproto.TrimString(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		h.Del(name)
+	}
+}
+
+// hopByHopWriter strips hop-by-hop headers a handler set before they're sent
+type hopByHopWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (h *hopByHopWriter) WriteHeader(statusCode int) {
+	if !h.wroteHeader {
+		h.wroteHeader = true
+		removeHopByHop(h.ResponseWriter.Header())
+	}
+	h.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (h *hopByHopWriter) Write(b []byte) (int, error) {
+	if !h.wroteHeader {
+		h.WriteHeader(http.StatusOK)
+	}
+	return h.ResponseWriter.Write(b)
+}
+
+func (h *hopByHopWriter) Flush() {
+	if f, ok := h.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
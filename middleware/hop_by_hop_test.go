@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHopByHopStripsRequestHeaders(t *testing.T) {
+	var seen http.Header
+	h := HopByHop(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Clone()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	req.Header.Set("Connection", "keep-alive, X-Internal-Hop")
+	req.Header.Set("X-Internal-Hop", "secret")
+	req.Header.Set("Keep-Alive", "timeout=5")
+	req.Header.Set("Te", "trailers")
+	req.Header.Set("Proxy-Authorization", "Basic Zm9vOmJhcg==")
+	req.Header.Set("X-Request-Id", "abc")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, name := range []string{"Connection", "X-Internal-Hop", "Keep-Alive", "Te", "Proxy-Authorization"} {
+		if v := seen.Get(name); v != "" {
+			t.Errorf("handler saw %s: %q", name, v)
+		}
+	}
+	if seen.Get("X-Request-Id") != "abc" {
+		t.Error("end-to-end header X-Request-Id was stripped")
+	}
+}
+
+func TestHopByHopStripsResponseHeaders(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		write func(w http.ResponseWriter)
+	}{
+		{"WriteHeader", func(w http.ResponseWriter) { w.WriteHeader(http.StatusOK) }},
+		{"Write", func(w http.ResponseWriter) { io.WriteString(w, "ok") }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := HopByHop(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Connection", "X-Debug-Hop")
+				w.Header().Set("X-Debug-Hop", "1")
+				w.Header().Set("Upgrade", "h2c")
+				w.Header().Set("Proxy-Authenticate", "Basic")
+				w.Header().Set("Content-Type", "text/plain")
+				tt.write(w)
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			for _, name := range []string{"Connection", "X-Debug-Hop", "Upgrade", "Proxy-Authenticate"} {
+				if v := rec.Header().Get(name); v != "" {
+					t.Errorf("response kept %s: %q", name, v)
+				}
+			}
+			if rec.Header().Get("Content-Type") != "text/plain" {
+				t.Error("end-to-end header Content-Type was stripped")
+			}
+		})
+	}
+}
+
+// net/http itself settles a chunked request that also carries
+// Content-Length, which is why HopByHop doesn't check for it
+func TestChunkedRequestReachesHandlerWithoutContentLength(t *testing.T) {
+	var contentLength string
+	var body []byte
+	srv := httptest.NewServer(HopByHop(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentLength = r.Header.Get("Content-Length")
+		body, _ = io.ReadAll(r.Body)
+	})))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 3\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+	if contentLength != "" {
+		t.Errorf("handler saw Content-Length %q alongside chunked framing", contentLength)
+	}
+	if string(body) != "hello" {
+		t.Errorf("handler read %q, want the chunked body", body)
+	}
+}
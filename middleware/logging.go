@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const slowRequestThreshold = time.Second
+
+// logSampleRate logs 1 in N successful requests; errors and slow requests
+// are always logged
+var logSampleRate atomic.Uint64
+
+func init() {
+	logSampleRate.Store(1)
+}
+
+// InitLogging reads LOG_SAMPLE_RATE (default 1, log everything)
+func InitLogging() {
+	if err := LoadLogSampleRate(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// LoadLogSampleRate applies LOG_SAMPLE_RATE, keeping the current rate on a
+// bad value
+func LoadLogSampleRate() error {
+	var rate uint64 = 1
+	if raw := os.Getenv("LOG_SAMPLE_RATE"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || parsed == 0 {
+			return fmt.Errorf("Invalid LOG_SAMPLE_RATE %q: must be a positive number", raw)
+		}
+		rate = parsed
+	}
+	logSampleRate.Store(rate)
+	return nil
+}
+
+// statusRecorder remembers the status code a handler wrote
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(statusCode int) {
+	s.status = statusCode
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Logging writes one line per request, sampling successful fast requests
+// according to LOG_SAMPLE_RATE
+func Logging(next http.Handler) http.Handler {
+	var successes uint64
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		elapsed := time.Since(start)
+
+		if shouldLog(rec.status, elapsed, &successes) {
+			log.Printf("%s %s %d %s", r.Method, r.URL.RequestURI(), rec.status, elapsed)
+		}
+	})
+}
+
+// shouldLog never samples out 4xx/5xx responses or slow requests; everything
+// else is counted and only every Nth one is logged
+func shouldLog(status int, elapsed time.Duration, successes *uint64) bool {
+	if status >= http.StatusBadRequest || elapsed >= slowRequestThreshold {
+		return true
+	}
+	return atomic.AddUint64(successes, 1)%logSampleRate.Load() == 0
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// logLines runs n requests answering status through Logging and returns how
+// many were logged
+func logLines(t *testing.T, n, status int) int {
+	t.Helper()
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	h := Logging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	for i := 0; i < n; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+	}
+	return strings.Count(out.String(), "GET /posts")
+}
+
+func useSampleRate(t *testing.T, rate string) {
+	t.Helper()
+	t.Setenv("LOG_SAMPLE_RATE", rate)
+	if err := LoadLogSampleRate(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logSampleRate.Store(1) })
+}
+
+func TestLoggingSamplesSuccesses(t *testing.T) {
+	useSampleRate(t, "10")
+	if got := logLines(t, 1000, http.StatusOK); got != 100 {
+		t.Errorf("logged %d of 1000 successes at 1 in 10, want 100", got)
+	}
+}
+
+func TestLoggingKeepsEveryError(t *testing.T) {
+	useSampleRate(t, "10")
+	for _, status := range []int{http.StatusNotFound, http.StatusInternalServerError} {
+		if got := logLines(t, 50, status); got != 50 {
+			t.Errorf("logged %d of 50 %d responses, want all", got, status)
+		}
+	}
+}
+
+func TestLoggingDefaultsToEverything(t *testing.T) {
+	useSampleRate(t, "")
+	if got := logLines(t, 20, http.StatusOK); got != 20 {
+		t.Errorf("logged %d of 20, want all without LOG_SAMPLE_RATE", got)
+	}
+}
+
+func TestLoadLogSampleRateKeepsRateOnBadValue(t *testing.T) {
+	useSampleRate(t, "5")
+	for _, raw := range []string{"0", "-2", "often"} {
+		t.Setenv("LOG_SAMPLE_RATE", raw)
+		if err := LoadLogSampleRate(); err == nil {
+			t.Errorf("LOG_SAMPLE_RATE=%q accepted", raw)
+		}
+		if got := logSampleRate.Load(); got != 5 {
+			t.Errorf("rate %d after LOG_SAMPLE_RATE=%q, want 5 kept", got, raw)
+		}
+	}
+}
+
+func TestShouldLogSlowRequests(t *testing.T) {
+	useSampleRate(t, "1000")
+	var successes uint64
+	if !shouldLog(http.StatusOK, slowRequestThreshold, &successes) {
+		t.Error("slow request sampled out")
+	}
+}
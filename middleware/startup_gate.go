@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"go-server/utils"
+	"net/http"
+	"sync/atomic"
+)
+
+// StartupGate answers 503 to everything but /healthz until Open is called,
+// so traffic that arrives while dependencies are still connecting gets a
+// clear "not ready" instead of a string of database errors
+type StartupGate struct {
+	ready atomic.Bool
+}
+
+// Open lets requests through from now on
+func (g *StartupGate) Open() {
+	g.ready.Store(true)
+}
+
+func (g *StartupGate) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !g.ready.Load() && r.URL.Path != "/healthz" {
+			w.Header().Set("Retry-After", "1")
+			utils.RespondWithError(w, http.StatusServiceUnavailable, utils.CodeNotReady, "Server is starting")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStartupGate(t *testing.T) {
+	var gate StartupGate
+	h := gate.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	status := func(path string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	if got := status("/posts"); got != http.StatusServiceUnavailable {
+		t.Errorf("before ready: /posts status %d, want 503", got)
+	}
+	if got := status("/healthz"); got != http.StatusOK {
+		t.Errorf("before ready: /healthz status %d, want 200", got)
+	}
+
+	gate.Open()
+	if got := status("/posts"); got != http.StatusOK {
+		t.Errorf("after ready: /posts status %d, want 200", got)
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+var (
+	// minBodyLength is the fewest characters a body may have after
+	// normalization; 0 only requires it to be non-empty
+	minBodyLength int
+	// bodyDisallowPattern rejects bodies it matches, e.g. links for a simple
+	// spam filter; nil when BODY_DISALLOW_PATTERN is unset
+	bodyDisallowPattern *regexp.Regexp
+)
+
+// InitBodyPolicy reads MIN_BODY_LENGTH and BODY_DISALLOW_PATTERN. The pattern
+// is compiled once here, so a bad one stops the server at startup rather than
+// failing every write.
+func InitBodyPolicy() {
+	if raw := os.Getenv("MIN_BODY_LENGTH"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid MIN_BODY_LENGTH %q: must be a non-negative integer", raw)
+		}
+		minBodyLength = n
+	}
+
+	if raw := os.Getenv("BODY_DISALLOW_PATTERN"); raw != "" {
+		re, err := regexp.Compile(raw)
+		if err != nil {
+			log.Fatalf("Invalid BODY_DISALLOW_PATTERN %q: %v", raw, err)
+		}
+		bodyDisallowPattern = re
+	}
+}
+
+// checkBodyPolicy applies the deployment's content rules to a non-empty body
+func checkBodyPolicy(body string) []FieldError {
+	var errs []FieldError
+	if n := utf8.RuneCountInString(body); n < minBodyLength {
+		errs = append(errs, FieldError{Field: "/body", Message: fmt.Sprintf("must be at least %d characters", minBodyLength)})
+	}
+	if bodyDisallowPattern != nil && bodyDisallowPattern.MatchString(body) {
+		errs = append(errs, FieldError{Field: "/body", Message: "contains disallowed content"})
+	}
+	return errs
+}
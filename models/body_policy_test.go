@@ -0,0 +1,34 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBodyPolicy(t *testing.T) {
+	defer func() { minBodyLength, bodyDisallowPattern = 0, nil }()
+	t.Setenv("MIN_BODY_LENGTH", "5")
+	t.Setenv("BODY_DISALLOW_PATTERN", `https?://`)
+	InitBodyPolicy()
+
+	tests := []struct {
+		body string
+		want []FieldError
+	}{
+		{"héllo", nil},
+		{"hi", []FieldError{{Field: "/body", Message: "must be at least 5 characters"}}},
+		{"see http://spam.example", []FieldError{{Field: "/body", Message: "contains disallowed content"}}},
+		{"x://", []FieldError{{Field: "/body", Message: "must be at least 5 characters"}}},
+	}
+	for _, tt := range tests {
+		if got := (Post{Body: tt.body}).Validate(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestBodyPolicyOffByDefault(t *testing.T) {
+	if errs := (Post{Body: "x http://a"}).Validate(); errs != nil {
+		t.Errorf("default policy rejected a body: %+v", errs)
+	}
+}
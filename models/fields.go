@@ -0,0 +1,135 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldInfo describes how clients may query one Post field. It is read from
+// the field's `query` struct tag, e.g. `query:"sort:desc,filter,select"`:
+//
+//	sort[:asc|:desc]  usable in ?sort=, with its default direction (asc if omitted)
+//	filter            usable as a filter criterion
+//	select            usable in ?fields= projections
+//	distinct          its distinct values may be listed
+//
+// Fields without a query tag can't be referenced from a query at all. Query
+// parameters may name a field by its stored name or by its JSON name, so
+// ?sort=created_at and ?sort=createdAt are the same.
+type FieldInfo struct {
+	Name             string // name used in query parameters (the stored field name)
+	JSONName         string // name the field has in responses
+	Sortable         bool
+	DefaultDirection int // 1 ascending, -1 descending
+	Filterable       bool
+	Selectable       bool
+	Distinct         bool
+}
+
+// PostFields is the registry of queryable Post fields, keyed by query name
+var PostFields = registerFields(reflect.TypeOf(Post{}))
+
+func registerFields(t reflect.Type) map[string]FieldInfo {
+	fields := make(map[string]FieldInfo)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("query")
+		if !ok {
+			continue
+		}
+
+		info := FieldInfo{
+			Name:     tagName(sf.Tag.Get("bson")),
+			JSONName: tagName(sf.Tag.Get("json")),
+		}
+		for _, opt := range strings.Split(tag, ",") {
+			switch opt {
+			case "sort", "sort:asc":
+				info.Sortable, info.DefaultDirection = true, 1
+			case "sort:desc":
+				info.Sortable, info.DefaultDirection = true, -1
+			case "filter":
+				info.Filterable = true
+			case "select":
+				info.Selectable = true
+			case "distinct":
+				info.Distinct = true
+			default:
+				panic(fmt.Sprintf("models: unknown query option %q on %s.%s", opt, t.Name(), sf.Name))
+			}
+		}
+		fields[info.Name] = info
+	}
+	return fields
+}
+
+// lookupField finds a queryable field by stored or JSON name
+func lookupField(name string) (FieldInfo, bool) {
+	if info, ok := PostFields[name]; ok {
+		return info, true
+	}
+	for _, info := range PostFields {
+		if info.JSONName == name {
+			return info, true
+		}
+	}
+	return FieldInfo{}, false
+}
+
+func tagName(tag string) string {
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
+
+// SortableField looks up a field that may be used in ?sort=
+func SortableField(name string) (FieldInfo, error) {
+	info, ok := lookupField(name)
+	if !ok || !info.Sortable {
+		return FieldInfo{}, fmt.Errorf("cannot sort by %q", name)
+	}
+	return info, nil
+}
+
+// FilterableField looks up a field that may be used as a filter criterion
+func FilterableField(name string) (FieldInfo, error) {
+	info, ok := lookupField(name)
+	if !ok || !info.Filterable {
+		return FieldInfo{}, fmt.Errorf("cannot filter by %q", name)
+	}
+	return info, nil
+}
+
+// SelectableField looks up a field that may be requested in ?fields=
+func SelectableField(name string) (FieldInfo, error) {
+	info, ok := lookupField(name)
+	if !ok || !info.Selectable {
+		return FieldInfo{}, fmt.Errorf("cannot select field %q", name)
+	}
+	return info, nil
+}
+
+// SelectFields renders p with only the given fields, keyed by their JSON names
+func SelectFields(p Post, fields []FieldInfo) map[string]interface{} {
+	data, _ := json.Marshal(p)
+	var all map[string]interface{}
+	json.Unmarshal(data, &all)
+
+	selected := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		if v, ok := all[f.JSONName]; ok {
+			selected[f.JSONName] = v
+		}
+	}
+	return selected
+}
+
+// DistinctField looks up a field whose distinct values may be listed
+func DistinctField(name string) (FieldInfo, error) {
+	info, ok := lookupField(name)
+	if !ok || !info.Distinct {
+		return FieldInfo{}, fmt.Errorf("cannot list distinct values of %q", name)
+	}
+	return info, nil
+}
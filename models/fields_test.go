@@ -0,0 +1,56 @@
+package models
+
+import "testing"
+
+func TestFieldLookupsRejectUnregisteredFields(t *testing.T) {
+	lookups := map[string]func(string) (FieldInfo, error){
+		"sort":     SortableField,
+		"filter":   FilterableField,
+		"select":   SelectableField,
+		"distinct": DistinctField,
+	}
+	// deleted_at exists on Post but has no query tag
+	for _, name := range []string{"deleted_at", "deletedAt", "password", "", "$where"} {
+		for feature, lookup := range lookups {
+			if _, err := lookup(name); err == nil {
+				t.Errorf("%s accepted unregistered field %q", feature, name)
+			}
+		}
+	}
+}
+
+func TestFieldLookupsFollowTags(t *testing.T) {
+	tests := []struct {
+		feature string
+		lookup  func(string) (FieldInfo, error)
+		allowed []string
+		denied  []string
+	}{
+		{"sort", SortableField, []string{"id", "created_at", "createdAt", "updatedAt"}, []string{"body", "tags", "version"}},
+		{"filter", FilterableField, []string{"id", "body", "tags"}, []string{"created_at", "views"}},
+		{"select", SelectableField, []string{"id", "objectId", "_id", "body", "views"}, nil},
+		{"distinct", DistinctField, []string{"tags"}, []string{"id", "body"}},
+	}
+	for _, tt := range tests {
+		for _, name := range tt.allowed {
+			if _, err := tt.lookup(name); err != nil {
+				t.Errorf("%s %q: %v", tt.feature, name, err)
+			}
+		}
+		for _, name := range tt.denied {
+			if _, err := tt.lookup(name); err == nil {
+				t.Errorf("%s accepted %q, which isn't tagged for it", tt.feature, name)
+			}
+		}
+	}
+}
+
+func TestFieldLookupsMapJSONNames(t *testing.T) {
+	info, err := SortableField("createdAt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "created_at" || info.DefaultDirection != -1 {
+		t.Errorf("createdAt = %+v, want stored name created_at sorted descending", info)
+	}
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldLimit caps the size of one Post field. It is read from the field's
+// `limit` struct tag, e.g. `limit:"len:50,items:20"`:
+//
+//	len:N    at most N characters; for a list, per item
+//	items:N  at most N items in a list
+//
+// These are checked in Validate on top of the overall request size limit, so
+// a client learns which field is too big rather than just that the body is.
+type FieldLimit struct {
+	index    int
+	JSONName string
+	MaxLen   int
+	MaxItems int
+}
+
+// PostLimits holds the size limits declared on Post
+var PostLimits = registerLimits(reflect.TypeOf(Post{}))
+
+func registerLimits(t reflect.Type) []FieldLimit {
+	var limits []FieldLimit
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("limit")
+		if !ok {
+			continue
+		}
+
+		limit := FieldLimit{index: i, JSONName: tagName(sf.Tag.Get("json"))}
+		for _, opt := range strings.Split(tag, ",") {
+			key, raw, _ := strings.Cut(opt, ":")
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 {
+				panic(fmt.Sprintf("models: invalid limit %q on %s.%s", opt, t.Name(), sf.Name))
+			}
+			switch key {
+			case "len":
+				limit.MaxLen = n
+			case "items":
+				limit.MaxItems = n
+			default:
+				panic(fmt.Sprintf("models: unknown limit option %q on %s.%s", opt, t.Name(), sf.Name))
+			}
+		}
+		limits = append(limits, limit)
+	}
+	return limits
+}
+
+// ValidateLimits checks every field against its declared size limit. Unlike
+// Validate it doesn't require any field, so it also fits partial updates.
+func (p Post) ValidateLimits() []FieldError {
+	v := reflect.ValueOf(p)
+	var errs []FieldError
+	for _, limit := range PostLimits {
+		errs = append(errs, limit.check(v.Field(limit.index))...)
+	}
+	return errs
+}
+
+func (l FieldLimit) check(v reflect.Value) []FieldError {
+	pointer := jsonPointer(l.JSONName)
+	switch v.Kind() {
+	case reflect.String:
+		if l.MaxLen > 0 && utf8.RuneCountInString(v.String()) > l.MaxLen {
+			return []FieldError{{Field: pointer, Message: fmt.Sprintf("must be at most %d characters", l.MaxLen)}}
+		}
+	case reflect.Slice:
+		var errs []FieldError
+		if l.MaxItems > 0 && v.Len() > l.MaxItems {
+			errs = append(errs, FieldError{Field: pointer, Message: fmt.Sprintf("must have at most %d items", l.MaxItems)})
+		}
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			if l.MaxLen > 0 && item.Kind() == reflect.String && utf8.RuneCountInString(item.String()) > l.MaxLen {
+				errs = append(errs, FieldError{Field: fmt.Sprintf("%s/%d", pointer, i), Message: fmt.Sprintf("must be at most %d characters", l.MaxLen)})
+			}
+		}
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateLimits(t *testing.T) {
+	manyTags := make([]string, 21)
+	for i := range manyTags {
+		manyTags[i] = fmt.Sprintf("t%d", i)
+	}
+
+	tests := []struct {
+		name string
+		post Post
+		want []FieldError
+	}{
+		{"at the limits", Post{Body: strings.Repeat("é", 10000), Tags: []string{strings.Repeat("x", 50)}}, nil},
+		{"long body", Post{Body: strings.Repeat("x", 10001)}, []FieldError{
+			{Field: "/body", Message: "must be at most 10000 characters"},
+		}},
+		{"long tag", Post{Body: "b", Tags: []string{"ok", strings.Repeat("x", 51)}}, []FieldError{
+			{Field: "/tags/1", Message: "must be at most 50 characters"},
+		}},
+		{"too many tags", Post{Body: "b", Tags: manyTags}, []FieldError{
+			{Field: "/tags", Message: "must have at most 20 items"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := tt.post.ValidateLimits(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// collapseWhitespace squeezes internal whitespace runs to a single space when
+// enabled through COLLAPSE_WHITESPACE
+var collapseWhitespace = false
+
+// InitNormalization reads the optional body normalization settings
+func InitNormalization() {
+	collapseWhitespace, _ = strconv.ParseBool(os.Getenv("COLLAPSE_WHITESPACE"))
+}
+
+// NormalizeBody puts a post body into the canonical form it is stored in:
+// Unicode NFC, no leading or trailing whitespace and, optionally, no runs of
+// internal whitespace
+func NormalizeBody(body string) string {
+	body = norm.NFC.String(body)
+	if collapseWhitespace {
+		return strings.Join(strings.Fields(body), " ")
+	}
+	return strings.TrimSpace(body)
+}
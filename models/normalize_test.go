@@ -0,0 +1,40 @@
+package models
+
+import "testing"
+
+func TestNormalizeBody(t *testing.T) {
+	defer func() { collapseWhitespace = false }()
+
+	tests := []struct {
+		in       string
+		collapse bool
+		want     string
+	}{
+		{"  hello world \n", false, "hello world"},
+		{"hello   big\t\nworld", false, "hello   big\t\nworld"},
+		{"  hello   big\t\nworld ", true, "hello big world"},
+		// e followed by a combining acute accent composes to é
+		{"cafe\u0301", false, "caf\u00e9"},
+		{"", false, ""},
+	}
+	for _, tt := range tests {
+		collapseWhitespace = tt.collapse
+		if got := NormalizeBody(tt.in); got != tt.want {
+			t.Errorf("NormalizeBody(%q) with collapse=%v = %q, want %q", tt.in, tt.collapse, got, tt.want)
+		}
+	}
+}
+
+func TestInitNormalization(t *testing.T) {
+	defer func() { collapseWhitespace = false }()
+	t.Setenv("COLLAPSE_WHITESPACE", "true")
+	InitNormalization()
+	if !collapseWhitespace {
+		t.Error("COLLAPSE_WHITESPACE=true not applied")
+	}
+	t.Setenv("COLLAPSE_WHITESPACE", "")
+	InitNormalization()
+	if collapseWhitespace {
+		t.Error("collapsing stays on without COLLAPSE_WHITESPACE")
+	}
+}
@@ -1,6 +1,48 @@
 package models
 
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Post is a stored post. The query tag registers a field for sorting,
+// filtering and projection; see FieldInfo. The limit tag caps a field's size;
+// see FieldLimit.
 type Post struct {
-	ID   int
-	Body string
+	ID int `json:"id" bson:"id" query:"sort,filter,select"`
+	// ObjectID is MongoDB's own _id, exposed so clients can start addressing
+	// posts by it; /posts/{id} accepts either form
+	ObjectID  primitive.ObjectID `json:"objectId" bson:"_id,omitempty" query:"select"`
+	Body      string             `json:"body" bson:"body" query:"filter,select" limit:"len:10000"`
+	Tags      []string           `json:"tags,omitempty" bson:"tags,omitempty" query:"filter,select,distinct" limit:"len:50,items:20"`
+	CreatedAt time.Time          `json:"createdAt" bson:"created_at" query:"sort:desc,select"`
+	UpdatedAt time.Time          `json:"updatedAt" bson:"updated_at" query:"sort:desc,select"`
+	// Version goes up by one on every write and guards conditional updates
+	Version int `json:"version" bson:"version" query:"select"`
+	// Views counts reads made with ?countView=true. It isn't content, so
+	// counting a view leaves Version and UpdatedAt alone.
+	Views int `json:"views" bson:"views" query:"select"`
+	// DeletedAt marks a soft-deleted post; only the change feed returns these
+	DeletedAt *time.Time `json:"deletedAt,omitempty" bson:"deleted_at,omitempty"`
+}
+
+// FieldError describes a single invalid field in a post payload, keyed by
+// the JSON pointer of the offending value
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validate checks the client-supplied fields of a post and returns every
+// problem found, or nil when the post can be stored
+func (p Post) Validate() []FieldError {
+	var errs []FieldError
+	if strings.TrimSpace(p.Body) == "" {
+		errs = append(errs, FieldError{Field: "/body", Message: "body is required"})
+	} else {
+		errs = append(errs, checkBodyPolicy(p.Body)...)
+	}
+	return append(errs, p.ValidateLimits()...)
 }
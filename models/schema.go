@@ -0,0 +1,132 @@
+package models
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+//go:embed schemas/post_create.json
+var createSchemaJSON string
+
+//go:embed schemas/post_update.json
+var updateSchemaJSON string
+
+var (
+	createSchema = jsonschema.MustCompileString("post_create.json", createSchemaJSON)
+	updateSchema = jsonschema.MustCompileString("post_update.json", updateSchemaJSON)
+)
+
+// ValidateCreatePayload checks a raw create request body against the post schema
+func ValidateCreatePayload(data []byte) []FieldError {
+	return validatePayload(createSchema, data)
+}
+
+// ValidateUpdatePayload checks a raw update request body against the update schema
+func ValidateUpdatePayload(data []byte) []FieldError {
+	return validatePayload(updateSchema, data)
+}
+
+// EditableFields lists the fields an update may set, in name order. The update
+// schema is the single source of truth for them.
+func EditableFields() []string {
+	names := make([]string, 0, len(updateSchema.Properties))
+	for name := range updateSchema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validatePayload returns one FieldError per schema violation, keyed by the
+// JSON pointer of the offending value
+func validatePayload(schema *jsonschema.Schema, data []byte) []FieldError {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return []FieldError{{Field: "", Message: "body must be valid JSON"}}
+	}
+
+	err := schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return []FieldError{{Field: "", Message: err.Error()}}
+	}
+
+	var errs []FieldError
+	var collect func(*jsonschema.ValidationError)
+	collect = func(ve *jsonschema.ValidationError) {
+		if len(ve.Causes) > 0 {
+			for _, cause := range ve.Causes {
+				collect(cause)
+			}
+			return
+		}
+		if strings.HasSuffix(ve.KeywordLocation, "/additionalProperties") {
+			errs = append(errs, unknownProperties(schema, ve.InstanceLocation, doc)...)
+			return
+		}
+		if strings.HasSuffix(ve.KeywordLocation, "/required") {
+			errs = append(errs, missingProperties(schema, ve.InstanceLocation, doc)...)
+			return
+		}
+		errs = append(errs, FieldError{Field: ve.InstanceLocation, Message: ve.Message})
+	}
+	collect(ve)
+	return errs
+}
+
+// missingProperties reports each absent required property under its own pointer
+func missingProperties(schema *jsonschema.Schema, location string, doc interface{}) []FieldError {
+	obj, ok := doc.(map[string]interface{})
+	if !ok || location != "" {
+		return []FieldError{{Field: location, Message: "missing required property"}}
+	}
+
+	var errs []FieldError
+	for _, name := range schema.Required {
+		if _, present := obj[name]; !present {
+			errs = append(errs, FieldError{Field: jsonPointer(name), Message: "is required"})
+		}
+	}
+	return errs
+}
+
+// unknownProperties reports each property the schema doesn't declare under its
+// own pointer, rather than the single object-level error the validator emits
+func unknownProperties(schema *jsonschema.Schema, location string, doc interface{}) []FieldError {
+	obj, ok := doc.(map[string]interface{})
+	if !ok || location != "" {
+		return []FieldError{{Field: location, Message: "unknown property"}}
+	}
+
+	var names []string
+	for name := range obj {
+		if _, declared := schema.Properties[name]; !declared {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	errs := make([]FieldError, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, FieldError{Field: jsonPointer(name), Message: "unknown property"})
+	}
+	return errs
+}
+
+// jsonPointer builds the pointer to a top-level property, escaping per RFC 6901
+func jsonPointer(name string) string {
+	return "/" + strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,46 @@
+package models
+
+import "testing"
+
+func TestValidatePayloadPointers(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func([]byte) []FieldError
+		payload  string
+		fields   []string
+	}{
+		{"valid create", ValidateCreatePayload, `{"body":"hi","tags":["a"]}`, nil},
+		{"wrong type", ValidateCreatePayload, `{"body":7}`, []string{"/body"}},
+		{"wrong item type", ValidateCreatePayload, `{"body":"hi","tags":["a",3]}`, []string{"/tags/1"}},
+		{"missing required", ValidateCreatePayload, `{"tags":["a"]}`, []string{"/body"}},
+		{"extra properties", ValidateCreatePayload, `{"body":"hi","title":"x","a/b":1}`, []string{"/a~1b", "/title"}},
+		{"update needs no body", ValidateUpdatePayload, `{"tags":["a"]}`, nil},
+		{"update rejects id", ValidateUpdatePayload, `{"id":5}`, []string{"/id"}},
+		{"not an object", ValidateCreatePayload, `["hi"]`, []string{""}},
+		{"not JSON", ValidateCreatePayload, `{"body":`, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.validate([]byte(tt.payload))
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if len(fields) != len(tt.fields) {
+				t.Fatalf("errors %+v, want pointers %q", errs, tt.fields)
+			}
+			for i := range fields {
+				if fields[i] != tt.fields[i] {
+					t.Errorf("errors %+v, want pointers %q", errs, tt.fields)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestEditableFields(t *testing.T) {
+	if got := EditableFields(); len(got) != 2 || got[0] != "body" || got[1] != "tags" {
+		t.Errorf("EditableFields() = %v, want [body tags]", got)
+	}
+}
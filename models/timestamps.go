@@ -0,0 +1,62 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// TimestampFormat is how every timestamp leaves the API: RFC 3339 in UTC
+// with exactly millisecond precision, which is also what MongoDB stores
+const TimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// Now is the current time as it will be stored: UTC, cut to milliseconds
+func Now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// FormatTimestamp renders t per TimestampFormat, whatever its location
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(TimestampFormat)
+}
+
+// MarshalJSON applies TimestampFormat to the post's timestamps, so the
+// server's TZ or a value's in-memory precision never leaks into responses
+func (p Post) MarshalJSON() ([]byte, error) {
+	type plain Post
+	out := struct {
+		plain
+		CreatedAt string  `json:"createdAt"`
+		UpdatedAt string  `json:"updatedAt"`
+		DeletedAt *string `json:"deletedAt,omitempty"`
+	}{
+		plain:     plain(p),
+		CreatedAt: FormatTimestamp(p.CreatedAt),
+		UpdatedAt: FormatTimestamp(p.UpdatedAt),
+	}
+	if p.DeletedAt != nil {
+		deletedAt := FormatTimestamp(*p.DeletedAt)
+		out.DeletedAt = &deletedAt
+	}
+	return json.Marshal(out)
+}
+
+// MarshalWithFields renders p followed by the fields of extra, a struct, as
+// one JSON object. Types that embed Post need it in their own MarshalJSON:
+// Post's MarshalJSON is promoted to them and would drop their extra fields.
+func MarshalWithFields(p Post, extra interface{}) ([]byte, error) {
+	post, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := json.Marshal(extra)
+	if err != nil {
+		return nil, err
+	}
+	fields = bytes.TrimPrefix(fields, []byte("{"))
+	if bytes.Equal(fields, []byte("}")) {
+		return post, nil
+	}
+	post = append(bytes.TrimSuffix(post, []byte("}")), ',')
+	return append(post, fields...), nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPostTimestampsSerializeAsUTCMillis(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	created := time.Date(2024, 5, 1, 9, 30, 0, 123456789, tokyo)
+	deleted := created.Add(time.Hour)
+	p := Post{ID: 1, Body: "b", CreatedAt: created, UpdatedAt: created.Add(time.Minute), DeletedAt: &deleted}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"createdAt": "2024-05-01T00:30:00.123Z",
+		"updatedAt": "2024-05-01T00:31:00.123Z",
+		"deletedAt": "2024-05-01T01:30:00.123Z",
+	}
+	for field, w := range want {
+		if got[field] != w {
+			t.Errorf("%s = %v, want %s", field, got[field], w)
+		}
+	}
+	if got["body"] != "b" || got["id"] != 1.0 {
+		t.Errorf("other fields lost: %s", data)
+	}
+}
+
+func TestNowIsUTCMillis(t *testing.T) {
+	now := Now()
+	if now.Location() != time.UTC {
+		t.Errorf("location %s, want UTC", now.Location())
+	}
+	if now.Nanosecond()%int(time.Millisecond) != 0 {
+		t.Errorf("%s has sub-millisecond precision", now)
+	}
+}
+
+func TestMarshalWithFields(t *testing.T) {
+	p := Post{ID: 1, Body: "b", CreatedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	p.UpdatedAt = p.CreatedAt
+
+	data, err := MarshalWithFields(p, struct {
+		Score float64 `json:"score"`
+	}{1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	if got["score"] != 1.5 || got["body"] != "b" || got["createdAt"] != "2024-05-01T00:00:00.000Z" {
+		t.Errorf("got %s", data)
+	}
+
+	// Extra fields that all omit themselves leave the post as is
+	data, err = MarshalWithFields(p, struct {
+		Score float64 `json:"score,omitempty"`
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, _ := json.Marshal(p); string(data) != string(plain) {
+		t.Errorf("got %s, want %s", data, plain)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"go-server/cache"
+	"go-server/handlers"
+	"go-server/middleware"
+	"go-server/utils"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/joho/godotenv"
+)
+
+// liveSettings can be re-applied on SIGHUP without dropping connections.
+// Each loader keeps its current value and returns an error on bad input, so
+// a typo in .env never takes a running server down.
+var liveSettings = []struct {
+	env  string
+	load func() error
+}{
+	{"CACHE_TTL", cache.LoadCacheTTL},
+	{"MAX_PAGE_LIMIT", utils.LoadPageLimits},
+	{"LOG_SAMPLE_RATE", middleware.LoadLogSampleRate},
+	{"MAX_BODY_BYTES", handlers.LoadBodyLimit},
+	{"MAX_UPDATE_FIELDS", handlers.LoadUpdateLimits},
+}
+
+// restartSettings are read once at startup (connections, listeners, CORS,
+// middleware setup). Changing them only takes effect after a full restart.
+// Every setting the server reads belongs in one of the two lists, except
+// ENV and ADMIN_TOKEN, which are read on each request and so apply on reload
+// as they are; reload_test.go checks that nothing is missing.
+var restartSettings = []string{
+	"MONGODB_URL", "REDIS_URL", "REDIS_PASSWORD", "REDIS_DB",
+	"REDIS_IDLE_TIMEOUT", "REDIS_MAX_CONN_AGE",
+	"STRICT_STARTUP", "WAIT_FOR_DEPENDENCIES", "HEALTH_CHECK_TIMEOUT", "READ_RETRIES",
+	"CORS_ALLOWED_ORIGINS", "CORS_ALLOW_CREDENTIALS", "CORS_WILDCARD_CREDENTIALS", "CORS_MAX_AGE",
+	"MAX_CONN_PER_IP", "HTTP_IDLE_TIMEOUT", "DISABLE_KEEPALIVES", "SHUTDOWN_TIMEOUT",
+	"CACHE_WRITE_POLICY", "CACHE_TTL_JITTER", "MAX_CACHE_VALUE_BYTES", "CACHE_DISABLED_ROUTES",
+	"CACHE_COMPRESS", "CACHE_COMPRESS_MIN_BYTES", "CACHE_WARMUP_WINDOW", "SERVE_STALE_ON_ERROR",
+	"GZIP_LEVEL", "MAX_DECOMPRESSED_BYTES", "DUPLICATE_PARAMS",
+	"COLLAPSE_WHITESPACE", "MIN_BODY_LENGTH", "BODY_DISALLOW_PATTERN",
+	"MAX_LIST_BYTES", "MAX_DISTINCT_RESULTS", "EXPORT_CONCURRENCY", "COUNT_SNAPSHOT_INTERVAL",
+	"READ_MODE", "EMPTY_UPDATE", "ROOT_MODE", "STRICT_DELETE", "STRICT_ACCEPT", "STRICT_CONTENT_TYPE",
+}
+
+// snapshotEnv records the values restartSettings started with
+func snapshotEnv(names []string) map[string]string {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		values[name] = os.Getenv(name)
+	}
+	return values
+}
+
+// watchReload re-reads configuration every time the process gets SIGHUP
+func watchReload() {
+	started := snapshotEnv(restartSettings)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloadConfig(started)
+		}
+	}()
+}
+
+func reloadConfig(started map[string]string) {
+	log.Println("SIGHUP received, reloading configuration")
+	if os.Getenv("ENV") != "production" {
+		if err := godotenv.Overload(); err != nil {
+			log.Printf("Reload: could not read .env: %v", err)
+		}
+	}
+
+	for _, s := range liveSettings {
+		if err := s.load(); err != nil {
+			log.Printf("Reload: %v; keeping current %s", err, s.env)
+			continue
+		}
+		log.Printf("Reload: applied %s=%q", s.env, os.Getenv(s.env))
+	}
+	for _, name := range restartSettings {
+		if os.Getenv(name) != started[name] {
+			log.Printf("Reload: %s changed but requires a full restart to take effect", name)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"go-server/utils"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// perRequestSettings are read on every request rather than loaded, so a
+// reload applies them without a loader
+var perRequestSettings = map[string]bool{"ENV": true, "ADMIN_TOKEN": true}
+
+// TestEverySettingIsClassified fails when a setting is read somewhere but is
+// in neither liveSettings nor restartSettings, so SIGHUP would silently
+// ignore changes to it
+func TestEverySettingIsClassified(t *testing.T) {
+	known := make(map[string]bool)
+	for _, s := range liveSettings {
+		known[s.env] = true
+	}
+	for _, name := range restartSettings {
+		known[name] = true
+	}
+
+	getenv := regexp.MustCompile(`os\.Getenv\("([A-Z0-9_]+)"\)`)
+	err := filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "frontend" || d.Name() == "dbtest" || strings.HasPrefix(d.Name(), ".")) && path != "." {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, m := range getenv.FindAllSubmatch(src, -1) {
+			name := string(m[1])
+			if !known[name] && !perRequestSettings[name] {
+				t.Errorf("%s reads %s, which is in neither liveSettings nor restartSettings", path, name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSIGHUPAppliesLiveSettings(t *testing.T) {
+	// Production skips .env, so only the variables set here count
+	t.Setenv("ENV", "production")
+	t.Setenv("MAX_PAGE_LIMIT", "")
+	if err := utils.LoadPageLimits(); err != nil {
+		t.Fatal(err)
+	}
+	before := utils.MaxPageLimit()
+	t.Cleanup(func() {
+		os.Unsetenv("MAX_PAGE_LIMIT")
+		utils.LoadPageLimits()
+	})
+
+	watchReload()
+	want := before + 7
+	t.Setenv("MAX_PAGE_LIMIT", strconv.Itoa(want))
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for utils.MaxPageLimit() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("MAX_PAGE_LIMIT is %d after SIGHUP, want %d", utils.MaxPageLimit(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloadLogsRestartSettings(t *testing.T) {
+	t.Setenv("ENV", "production")
+	t.Setenv("READ_RETRIES", "2")
+	started := snapshotEnv(restartSettings)
+	t.Setenv("READ_RETRIES", "5")
+	t.Setenv("MAX_PAGE_LIMIT", "not a number")
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+	reloadConfig(started)
+
+	logged := out.String()
+	if !strings.Contains(logged, "READ_RETRIES changed but requires a full restart") {
+		t.Errorf("restart-only change not reported:\n%s", logged)
+	}
+	if !strings.Contains(logged, "keeping current MAX_PAGE_LIMIT") {
+		t.Errorf("invalid live value not reported:\n%s", logged)
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import "net/http"
+
+// ErrorCode is a stable, machine-readable error identifier. Unlike HTTP
+// statuses, which several failures share, each code means one thing, so
+// clients can branch on it safely.
+type ErrorCode string
+
+const (
+	CodeInvalidRequest       ErrorCode = "INVALID_REQUEST"
+	CodeValidationFailed     ErrorCode = "VALIDATION_FAILED"
+	CodeUnknownField         ErrorCode = "UNKNOWN_FIELD"
+	CodeImmutableField       ErrorCode = "IMMUTABLE_FIELD"
+	CodeEmptyUpdate          ErrorCode = "EMPTY_UPDATE"
+	CodeUnauthorized         ErrorCode = "UNAUTHORIZED"
+	CodeForbidden            ErrorCode = "FORBIDDEN"
+	CodeNotFound             ErrorCode = "NOT_FOUND"
+	CodePostNotFound         ErrorCode = "POST_NOT_FOUND"
+	CodeMethodNotAllowed     ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeNotAcceptable        ErrorCode = "NOT_ACCEPTABLE"
+	CodeVersionConflict      ErrorCode = "VERSION_CONFLICT"
+	CodePreconditionFailed   ErrorCode = "PRECONDITION_FAILED"
+	CodePayloadTooLarge      ErrorCode = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMediaType ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	CodeInternal             ErrorCode = "INTERNAL_ERROR"
+	CodeDBUnavailable        ErrorCode = "DB_UNAVAILABLE"
+	CodeCacheUnavailable     ErrorCode = "CACHE_UNAVAILABLE"
+	CodeCacheDisabled        ErrorCode = "CACHE_DISABLED"
+	CodeOverloaded           ErrorCode = "OVERLOADED"
+	CodeNotReady             ErrorCode = "NOT_READY"
+)
+
+// statusCodes is the code used when a caller doesn't name a more specific one
+var statusCodes = map[int]ErrorCode{
+	http.StatusBadRequest:            CodeInvalidRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusNotAcceptable:         CodeNotAcceptable,
+	http.StatusConflict:              CodeVersionConflict,
+	http.StatusPreconditionFailed:    CodePreconditionFailed,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   CodeValidationFailed,
+	http.StatusServiceUnavailable:    CodeOverloaded,
+}
+
+type ErrorResponse struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	Status  int       `json:"status"`
+}
+
+// RespondWithError writes a JSON error body carrying both the HTTP status and
+// the stable error code
+func RespondWithError(w http.ResponseWriter, status int, code ErrorCode, message string) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	RespondWithStatus(w, status, ErrorResponse{Code: code, Message: message, Status: status})
+}
+
+// Error is a drop-in for http.Error that answers in JSON, using the default
+// code for the status
+func Error(w http.ResponseWriter, message string, status int) {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = CodeInternal
+	}
+	RespondWithError(w, status, code, message)
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorUsesStatusCode(t *testing.T) {
+	tests := []struct {
+		status int
+		want   ErrorCode
+	}{
+		{http.StatusBadRequest, CodeInvalidRequest},
+		{http.StatusNotFound, CodeNotFound},
+		{http.StatusUnprocessableEntity, CodeValidationFailed},
+		{http.StatusInternalServerError, CodeInternal},
+		{http.StatusTeapot, CodeInternal},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		Error(rec, "boom", tt.status)
+
+		var resp ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode %q: %v", rec.Body, err)
+		}
+		if rec.Code != tt.status || resp.Status != tt.status || resp.Code != tt.want || resp.Message != "boom" {
+			t.Errorf("Error(%d) = %d %+v, want code %q", tt.status, rec.Code, resp, tt.want)
+		}
+	}
+}
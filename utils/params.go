@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Policies for a query parameter given more than once, e.g. ?limit=5&limit=10
+const (
+	// DuplicateParamsFirst uses the first value, like url.Values.Get
+	DuplicateParamsFirst = "first"
+	// DuplicateParamsLast uses the last value
+	DuplicateParamsLast = "last"
+	// DuplicateParamsReject answers 400 instead of guessing
+	DuplicateParamsReject = "reject"
+)
+
+var duplicateParams = DuplicateParamsFirst
+
+// InitDuplicateParams reads DUPLICATE_PARAMS (default first)
+func InitDuplicateParams() {
+	raw := os.Getenv("DUPLICATE_PARAMS")
+	switch raw {
+	case "":
+		return
+	case DuplicateParamsFirst, DuplicateParamsLast, DuplicateParamsReject:
+		duplicateParams = raw
+	default:
+		log.Fatalf("Invalid DUPLICATE_PARAMS %q: must be %s, %s or %s", raw, DuplicateParamsFirst, DuplicateParamsLast, DuplicateParamsReject)
+	}
+}
+
+// QueryParam returns the value of a query parameter according to the
+// DUPLICATE_PARAMS policy. Under reject, requests with repeated parameters
+// never reach a handler (see CheckDuplicateParams), so the first value is
+// the only one.
+func QueryParam(r *http.Request, name string) string {
+	values := r.URL.Query()[name]
+	if len(values) == 0 {
+		return ""
+	}
+	if duplicateParams == DuplicateParamsLast {
+		return values[len(values)-1]
+	}
+	return values[0]
+}
+
+// CheckDuplicateParams returns an error naming the repeated query parameters
+// when the policy is reject. No endpoint takes a parameter more than once;
+// lists are comma-separated (?tags=a,b).
+func CheckDuplicateParams(r *http.Request) error {
+	if duplicateParams != DuplicateParamsReject {
+		return nil
+	}
+	var repeated []string
+	for name, values := range r.URL.Query() {
+		if len(values) > 1 {
+			repeated = append(repeated, name)
+		}
+	}
+	if len(repeated) == 0 {
+		return nil
+	}
+	sort.Strings(repeated)
+	return fmt.Errorf("Query parameters may only be given once: %s", strings.Join(repeated, ", "))
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func useDuplicateParams(t *testing.T, policy string) {
+	t.Helper()
+	old := duplicateParams
+	duplicateParams = policy
+	t.Cleanup(func() { duplicateParams = old })
+}
+
+func TestRepeatedParamsFirstAndLast(t *testing.T) {
+	tests := []struct {
+		policy        string
+		limit, offset int
+		field         string
+		direction     int
+	}{
+		{DuplicateParamsFirst, 5, 1, "id", -1},
+		{DuplicateParamsLast, 10, 2, "created_at", 1},
+	}
+	for _, tt := range tests {
+		useDuplicateParams(t, tt.policy)
+		r := httptest.NewRequest(http.MethodGet,
+			"/posts?limit=5&limit=10&offset=1&offset=2&sort=id&sort=created_at&order=desc&order=asc", nil)
+
+		if err := CheckDuplicateParams(r); err != nil {
+			t.Errorf("%s: CheckDuplicateParams = %v", tt.policy, err)
+		}
+		limit, offset := ParsePaginationParams(r)
+		if limit != tt.limit || offset != tt.offset {
+			t.Errorf("%s: limit, offset = %d, %d; want %d, %d", tt.policy, limit, offset, tt.limit, tt.offset)
+		}
+		field, direction, err := ParseSortParams(r)
+		if err != nil || field != tt.field || direction != tt.direction {
+			t.Errorf("%s: sort = %s %d (%v); want %s %d", tt.policy, field, direction, err, tt.field, tt.direction)
+		}
+	}
+}
+
+func TestRepeatedParamsReject(t *testing.T) {
+	useDuplicateParams(t, DuplicateParamsReject)
+
+	r := httptest.NewRequest(http.MethodGet, "/posts?sort=id&limit=5&offset=1&limit=10&sort=id&tags=a", nil)
+	err := CheckDuplicateParams(r)
+	if err == nil || err.Error() != "Query parameters may only be given once: limit, sort" {
+		t.Errorf("CheckDuplicateParams = %v, want limit and sort named", err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/posts?limit=5&offset=1&tags=a,b", nil)
+	if err := CheckDuplicateParams(r); err != nil {
+		t.Errorf("no repeats: CheckDuplicateParams = %v", err)
+	}
+}
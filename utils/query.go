@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"fmt"
+	"go-server/models"
+	"net/http"
+)
+
+// DefaultSortField is used when ?sort= is absent. Each sortable field's
+// default direction comes from its query tag on models.Post.
+const DefaultSortField = "id"
+
+// ParseSortParams reads ?sort= and ?order=, returning the stored field name and
+// a Mongo sort direction (1 ascending, -1 descending)
+func ParseSortParams(r *http.Request) (field string, direction int, err error) {
+	name := QueryParam(r, "sort")
+	if name == "" {
+		name = DefaultSortField
+	}
+
+	sf, err := models.SortableField(name)
+	if err != nil {
+		return "", 0, err
+	}
+
+	direction = sf.DefaultDirection
+	switch order := QueryParam(r, "order"); order {
+	case "":
+	case "asc":
+		direction = 1
+	case "desc":
+		direction = -1
+	default:
+		return "", 0, fmt.Errorf("order must be asc or desc, got %q", order)
+	}
+	return sf.Name, direction, nil
+}
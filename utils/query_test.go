@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseSortParams(t *testing.T) {
+	tests := []struct {
+		query     string
+		field     string
+		direction int
+	}{
+		{"", "id", 1},
+		{"sort=id", "id", 1},
+		{"sort=created_at", "created_at", -1},
+		{"sort=createdAt", "created_at", -1},
+		{"sort=updated_at", "updated_at", -1},
+		{"sort=created_at&order=asc", "created_at", 1},
+		{"sort=id&order=desc", "id", -1},
+		{"order=desc", "id", -1},
+	}
+	for _, tt := range tests {
+		field, direction, err := ParseSortParams(httptest.NewRequest(http.MethodGet, "/posts?"+tt.query, nil))
+		if err != nil || field != tt.field || direction != tt.direction {
+			t.Errorf("%q: got %s %d (%v), want %s %d", tt.query, field, direction, err, tt.field, tt.direction)
+		}
+	}
+}
+
+func TestParseSortParamsRejects(t *testing.T) {
+	for _, query := range []string{"sort=body", "sort=nope", "sort=id&order=up"} {
+		if _, _, err := ParseSortParams(httptest.NewRequest(http.MethodGet, "/posts?"+query, nil)); err == nil {
+			t.Errorf("%q accepted", query)
+		}
+	}
+}
@@ -4,10 +4,52 @@ import (
 	"encoding/json"
 	"fmt"
 	"go-server/models"
+	"log"
 	"net/http"
+	"os"
 	"strconv"
+	"sync/atomic"
 )
 
+const (
+	DefaultPageLimit    = 10
+	defaultMaxPageLimit = 100
+)
+
+// maxPageLimit caps ?limit= so one request can't pull the whole collection
+var maxPageLimit atomic.Int64
+
+func init() {
+	maxPageLimit.Store(defaultMaxPageLimit)
+}
+
+// MaxPageLimit returns the current cap on ?limit=
+func MaxPageLimit() int {
+	return int(maxPageLimit.Load())
+}
+
+// InitPageLimits reads MAX_PAGE_LIMIT
+func InitPageLimits() {
+	if err := LoadPageLimits(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// LoadPageLimits applies MAX_PAGE_LIMIT, keeping the current cap on a bad
+// value
+func LoadPageLimits() error {
+	n := defaultMaxPageLimit
+	if raw := os.Getenv("MAX_PAGE_LIMIT"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < DefaultPageLimit {
+			return fmt.Errorf("Invalid MAX_PAGE_LIMIT %q: must be an integer of at least %d", raw, DefaultPageLimit)
+		}
+		n = parsed
+	}
+	maxPageLimit.Store(int64(n))
+	return nil
+}
+
 type ResponseWithMeta struct {
 	Post           models.Post `json:"post"`
 	Source         string      `json:"source"`
@@ -20,6 +62,14 @@ func RespondWithJSON(w http.ResponseWriter, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// RespondWithRawJSON writes an already encoded JSON body, ending it with a
+// newline like RespondWithJSON does
+func RespondWithRawJSON(w http.ResponseWriter, data []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+	w.Write([]byte("\n"))
+}
+
 func RespondWithStatus(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -27,25 +77,32 @@ func RespondWithStatus(w http.ResponseWriter, statusCode int, data interface{})
 }
 
 func RespondWithMetadata(w http.ResponseWriter, post models.Post, source string, duration int64, fromCache bool) {
+	SetMetadataHeaders(w, duration, fromCache)
+	RespondWithJSON(w, ResponseWithMeta{Post: post, Source: source, ResponseTimeMs: duration})
+}
+
+// SetMetadataHeaders sets X-Cache and X-Response-Time-Ms, for responses that
+// carry the metadata in headers only
+func SetMetadataHeaders(w http.ResponseWriter, duration int64, fromCache bool) {
+	// Callers may already have marked the cache as DISABLED
 	if fromCache {
 		w.Header().Set("X-Cache", "HIT")
-	} else {
+	} else if w.Header().Get("X-Cache") == "" {
 		w.Header().Set("X-Cache", "MISS")
 	}
 	w.Header().Set("X-Response-Time-Ms", fmt.Sprintf("%d", duration))
-	RespondWithJSON(w, ResponseWithMeta{Post: post, Source: source, ResponseTimeMs: duration})
 }
 
 func ParsePaginationParams(r *http.Request) (limit, offset int) {
-	limit, offset = 10, 0
+	limit, offset = DefaultPageLimit, 0
 
-	if l := r.URL.Query().Get("limit"); l != "" {
+	if l := QueryParam(r, "limit"); l != "" {
 		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
-			limit = parsed
+			limit = min(parsed, MaxPageLimit())
 		}
 	}
 
-	if o := r.URL.Query().Get("offset"); o != "" {
+	if o := QueryParam(r, "offset"); o != "" {
 		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
 			offset = parsed
 		}